@@ -0,0 +1,116 @@
+package godump
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// BytesMode defines how byte slices and arrays are printed.
+type BytesMode int
+
+const (
+	// BytesAsNumbers prints each byte as a number on its own line. This is the default.
+	BytesAsNumbers BytesMode = iota
+
+	// BytesAsHex prints bytes as a hex dump, in rows of 16 bytes along with their offset and ASCII representation.
+	BytesAsHex
+
+	// BytesAsText prints bytes as a quoted string when they are valid UTF-8, and as a hex dump otherwise.
+	BytesAsText
+)
+
+// bytesPerRow is the number of bytes printed in each row of hex dumps.
+const bytesPerRow = 16
+
+// isBytes reports whether `v` is a slice or an array of bytes.
+func isBytes(v reflect.Value) bool {
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8
+}
+
+// bytesOf returns the content of the byte slice or array `v`.
+func bytesOf(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice || v.CanAddr() {
+		return v.Bytes()
+	}
+
+	b := make([]byte, v.Len())
+	for i := range b {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	return b
+}
+
+// dumpBytes writes the content of the byte slice or array `v` according to [Dumper.Bytes], after its type.
+func (d *renderer) dumpBytes(v reflect.Value, tag string) {
+	b := bytesOf(v)
+
+	if d.Bytes == BytesAsText && utf8.Valid(b) {
+		d.buf.WriteString(__(d.Theme.Braces, "(") + d.quote(string(b)) + __(d.Theme.Braces, ")"))
+		d.buf.WriteString(tag)
+		return
+	}
+
+	d.buf.WriteString(__(d.Theme.Braces, fmt.Sprintf(" {%s", tag)))
+
+	rows := (len(b) + bytesPerRow - 1) / bytesPerRow
+	if rows > 0 && d.fold(tag, "") {
+		return
+	}
+
+	head, skipped := d.elide(rows)
+
+	d.depth++
+	for i := 0; i < rows; i++ {
+		if d.truncated() {
+			break
+		}
+
+		if i == head && skipped > 0 {
+			d.writeElision(skipped)
+			i += skipped - 1
+			continue
+		}
+
+		d.buf.WriteString("\n")
+		d.indent()
+		d.writeHexRow(b, i*bytesPerRow)
+	}
+	d.depth--
+
+	if rows > 0 {
+		d.buf.WriteString("\n")
+		d.indent()
+	}
+
+	d.buf.WriteString(__(d.Theme.Braces, "}"))
+}
+
+// writeHexRow writes the row of the hex dump of `b` starting at `offset`, the way 'hexdump -C' does.
+func (d *renderer) writeHexRow(b []byte, offset int) {
+	row := b[offset:min(offset+bytesPerRow, len(b))]
+
+	var hex, ascii strings.Builder
+	for i := 0; i < bytesPerRow; i++ {
+		if i == bytesPerRow/2 {
+			hex.WriteString(" ")
+		}
+
+		if i >= len(row) {
+			hex.WriteString("   ")
+			continue
+		}
+
+		fmt.Fprintf(&hex, " %02x", row[i])
+		if row[i] >= ' ' && row[i] <= '~' {
+			ascii.WriteByte(row[i])
+		} else {
+			ascii.WriteByte('.')
+		}
+	}
+
+	d.buf.WriteString(__(d.Theme.Number, fmt.Sprintf("%08x", offset)))
+	d.buf.WriteString(" " + __(d.Theme.Number, hex.String()) + "  ")
+	d.buf.WriteString(__(d.Theme.Braces, "|") + __(d.Theme.String, ascii.String()) + __(d.Theme.Braces, "|"))
+}
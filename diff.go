@@ -0,0 +1,528 @@
+package godump
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/yassinebenaid/godump/internal/lcs"
+)
+
+// Diff returns the differences between `a` and `b` using the default Dumper options and the default theme,
+// or an empty string if there are none. See [Dumper.Fdiff].
+func Diff(a, b any) string {
+	var s strings.Builder
+	_ = (&Dumper{Theme: DefaultTheme}).Fdiff(&s, a, b)
+	return s.String()
+}
+
+// Fdiff writes the differences between `a` and `b` to `dst`, nothing is written if there are none.
+//
+// Both values are walked the way the text format walks them, and the differences are printed with the same layout.
+// Lines of `a` are marked with '-', lines of `b` with '+', and the enclosing lines of the paths leading to them with
+// a space. Unchanged fields, entries and items are omitted, except for the [Dumper.DiffContext] ones around each
+// change. Map entries are matched by key, and the items of slices and arrays are aligned so that inserting or
+// removing one does not change the others. Pointer ids and capacities of slices are not compared. Redacted values
+// are compared without being printed, a changed one is marked as "(changed)".
+//
+// [Dumper.Format] and the options specific to the text format, except [Dumper.Escaping] and [Dumper.MaxStringLen],
+// are ignored.
+//
+// It returns a write error if encountered while writing to `dst`.
+func (d *Dumper) Fdiff(dst io.Writer, a, b any) error {
+	r := d.newRenderer()
+	r.diff(a, b)
+	if _, err := r.buf.WriteTo(dst); err != nil {
+		return fmt.Errorf("dumper error: encountered unexpected write error, %v", err)
+	}
+	return nil
+}
+
+// diff writes the differences between `a` and `b` to the buffer.
+func (d *renderer) diff(a, b any) {
+	d.Format, d.MultilineStrings, d.MaxBytes = FormatText, false, 0
+	d.diffing = true
+
+	na := d.build(d.init(a))
+	nb := d.build(d.init(b))
+	d.buf.Reset()
+
+	w := differ{d: d, ids: make(map[uint]uint)}
+	if w.same(na, nb) {
+		return
+	}
+
+	w.diff(row{}, na, nb)
+	d.buf.Next(1) // the new line starting the first line.
+}
+
+// differ writes the differences between two trees of nodes, see [Dumper.Fdiff].
+type differ struct {
+	d *renderer
+
+	// ids maps the pointer ids of the first tree to the ids of the same values in the second one.
+	ids map[uint]uint
+}
+
+// row is a struct field, a map entry or a list item, as found in either tree or both.
+type row struct {
+	// name is the name of struct fields.
+	name string
+
+	// key is the key of map entries.
+	key *node
+
+	// a and b are the values in the first and the second tree, either one is nil if the other has no match.
+	a, b *node
+
+	// elided is the number of items or entries elided in place of the row because of [Dumper.MaxItems].
+	elided int
+
+	// suffix is written after the value, it is the comma ending the elements of structs, maps and lists.
+	suffix string
+}
+
+// same reports whether the nodes `a` and `b` are the same regardless of their pointer ids, and records
+// the ids they correspond to if so.
+func (w *differ) same(a, b *node) bool {
+	ids := make(map[uint]uint)
+	if !w.match(a, b, ids) {
+		return false
+	}
+
+	for k, v := range ids {
+		w.ids[k] = v
+	}
+	return true
+}
+
+// match reports whether the nodes `a` and `b` are the same, references being the same if their ids correspond
+// to each other, as recorded in `ids` while walking them.
+func (w *differ) match(a, b *node, ids map[uint]uint) bool {
+	if a.kind != b.kind || a.typ != b.typ || a.text != b.text || a.folded != b.folded || a.len != b.len ||
+		a.head != b.head || a.elided != b.elided || (a.id == 0) != (b.id == 0) || a.hidden != b.hidden {
+		return false
+	}
+
+	if a.kind == nodeRef {
+		id, ok := ids[a.id]
+		if !ok {
+			id, ok = w.ids[a.id]
+		}
+		return ok && id == b.id
+	}
+
+	if a.id != 0 {
+		ids[a.id] = b.id
+	}
+
+	switch a.kind {
+	case nodePointer:
+		return w.match(a.elem, b.elem, ids)
+	case nodeStruct:
+		if len(a.fields) != len(b.fields) {
+			return false
+		}
+		for i := range a.fields {
+			if !w.match(a.fields[i].value, b.fields[i].value, ids) {
+				return false
+			}
+		}
+	case nodeMap:
+		if len(a.entries) != len(b.entries) {
+			return false
+		}
+		for i := range a.entries {
+			if !w.match(a.entries[i].key, b.entries[i].key, ids) || !w.match(a.entries[i].value, b.entries[i].value, ids) {
+				return false
+			}
+		}
+	case nodeList:
+		if len(a.items) != len(b.items) {
+			return false
+		}
+		for i := range a.items {
+			if !w.match(a.items[i], b.items[i], ids) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// diff writes the differences between the nodes `a` and `b` of the row `r`. Structs, maps and lists of
+// the same type are compared element by element, other values are printed in full from both trees.
+func (w *differ) diff(r row, a, b *node) {
+	switch {
+	case a == nil:
+		w.write('+', r, b)
+		return
+	case b == nil:
+		w.write('-', r, a)
+		return
+	case w.same(a, b):
+		w.write(' ', r, b)
+		return
+	case a.kind == nodeRedacted && b.kind == nodeRedacted && a.typ == b.typ:
+		w.write('-', r, a)
+		w.line('+')
+		w.label('+', r)
+		w.d.writeRedacted(b.typ)
+		w.d.buf.WriteString(" " + __(w.d.Theme.Elision, "(changed)") + r.suffix)
+		return
+	}
+
+	var prefix string
+	ea, eb := a, b
+	for ea.kind == nodePointer && eb.kind == nodePointer && ea.typ == eb.typ {
+		if ea.id != 0 {
+			w.ids[ea.id] = eb.id
+		}
+		prefix += __(w.d.Theme.Address, "&")
+		ea, eb = ea.elem, eb.elem
+	}
+
+	if (ea.kind == nodeStruct || ea.kind == nodeMap || ea.kind == nodeList) &&
+		ea.kind == eb.kind && ea.typ == eb.typ && !ea.folded && !eb.folded {
+		w.diffComposite(r, prefix, ea, eb)
+		return
+	}
+
+	w.write('-', r, a)
+	w.write('+', r, b)
+}
+
+// diffComposite writes the differences between the elements of the struct, map or list nodes `a` and `b`
+// of the same type, which are reached through the pointers written as `prefix`.
+func (w *differ) diffComposite(r row, prefix string, a, b *node) {
+	d := w.d
+
+	if a.id != 0 {
+		w.ids[a.id] = b.id
+	}
+
+	if a.len != b.len {
+		w.open('-', r, prefix, a)
+		w.open('+', r, prefix, b)
+	} else {
+		w.open(' ', r, prefix, b)
+	}
+
+	var rows []row
+	switch a.kind {
+	case nodeStruct:
+		rows = w.fields(a, b)
+	case nodeMap:
+		rows = w.entries(a, b)
+	default:
+		rows = w.items(a, b)
+	}
+
+	changed := make([]bool, len(rows))
+	for i, r := range rows {
+		changed[i] = r.elided == 0 && (r.a == nil || r.b == nil || !w.same(r.a, r.b))
+	}
+
+	context := int(d.DiffContext)
+	near := func(i int) bool {
+		for j := max(0, i-context); j <= min(len(rows)-1, i+context); j++ {
+			if changed[j] {
+				return true
+			}
+		}
+		return false
+	}
+
+	d.depth++
+	for i, unchanged := 0, 0; i <= len(rows); i++ {
+		if i < len(rows) && !near(i) {
+			unchanged++
+			continue
+		}
+
+		if unchanged > 0 {
+			w.line(' ')
+			d.buf.WriteString(__(d.Theme.Elision, fmt.Sprintf("… %s unchanged …", thousands(unchanged))))
+			unchanged = 0
+		}
+
+		switch {
+		case i == len(rows):
+		case rows[i].elided > 0:
+			w.line(' ')
+			d.buf.WriteString(__(d.Theme.Elision, fmt.Sprintf("… %s more …", thousands(rows[i].elided))))
+		default:
+			w.diff(rows[i], rows[i].a, rows[i].b)
+		}
+	}
+	d.depth--
+
+	w.line(' ')
+	d.buf.WriteString(__(d.Theme.Braces, "}") + r.suffix)
+}
+
+// fields returns the rows of the fields of the struct nodes `a` and `b` of the same type.
+func (w *differ) fields(a, b *node) []row {
+	rows := make([]row, len(b.fields))
+	for i, f := range b.fields {
+		rows[i] = row{name: f.name, a: a.fields[i].value, b: f.value, suffix: ","}
+	}
+	return rows
+}
+
+// entries returns the rows of the entries of the map nodes `a` and `b`, matched by key. Entries are ordered
+// the way they are in `a`, entries found only in `b` are placed after the ones that precede them in `b`.
+func (w *differ) entries(a, b *node) []row {
+	sigs := make([]string, len(b.entries))
+	index := make(map[string]int, len(b.entries))
+	for i, e := range b.entries {
+		sigs[i] = w.signature(e.key)
+		index[sigs[i]] = i
+	}
+
+	inA := make(map[string]bool, len(a.entries))
+	for _, e := range a.entries {
+		inA[w.signature(e.key)] = true
+	}
+
+	var rows []row
+	done := make([]bool, len(b.entries))
+	next := 0
+
+	// added appends the entries found only in `b` up to the index `until`.
+	added := func(until int) {
+		for ; next < until; next++ {
+			if !inA[sigs[next]] {
+				done[next] = true
+				rows = append(rows, row{key: b.entries[next].key, b: b.entries[next].value, suffix: ","})
+			}
+		}
+	}
+
+	for _, e := range a.entries {
+		i, ok := index[w.signature(e.key)]
+		if !ok || done[i] {
+			rows = append(rows, row{key: e.key, a: e.value, suffix: ","})
+			continue
+		}
+
+		added(i)
+		done[i] = true
+		rows = append(rows, row{key: b.entries[i].key, a: e.value, b: b.entries[i].value, suffix: ","})
+	}
+	added(len(b.entries))
+
+	for i, e := range b.entries {
+		if !done[i] {
+			rows = append(rows, row{key: e.key, b: e.value, suffix: ","})
+		}
+	}
+
+	return w.withElision(rows, a, b)
+}
+
+// items returns the rows of the items of the list nodes `a` and `b`, aligned along their longest common
+// subsequence, so that items inserted or removed are rows of their own. Removed items directly followed by
+// inserted ones are paired so that their differences can be printed.
+func (w *differ) items(a, b *node) []row {
+	if a.elided > 0 || b.elided > 0 {
+		var rows []row
+		for i := 0; i < max(len(a.items), len(b.items)); i++ {
+			r := row{suffix: ","}
+			if i < len(a.items) {
+				r.a = a.items[i]
+			}
+			if i < len(b.items) {
+				r.b = b.items[i]
+			}
+			rows = append(rows, r)
+		}
+		return w.withElision(rows, a, b)
+	}
+
+	x, y := a.items, b.items
+	ops := lcs.Align(len(x), len(y), maxAlignment, func(i, j int) bool { return w.same(x[i], y[j]) })
+
+	var rows []row
+	var removed, added []*node
+	pair := func() {
+		for k := 0; k < max(len(removed), len(added)); k++ {
+			r := row{suffix: ","}
+			if k < len(removed) {
+				r.a = removed[k]
+			}
+			if k < len(added) {
+				r.b = added[k]
+			}
+			rows = append(rows, r)
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for _, op := range ops {
+		switch op {
+		case lcs.Keep:
+			pair()
+			rows = append(rows, row{a: x[i], b: y[j], suffix: ","})
+			i++
+			j++
+		case lcs.Remove:
+			removed = append(removed, x[i])
+			i++
+		default:
+			added = append(added, y[j])
+			j++
+		}
+	}
+	pair()
+
+	return rows
+}
+
+// maxAlignment is the largest number of comparisons made to align the items of lists, above which the items
+// that differ are aligned by index.
+const maxAlignment = 1 << 16
+
+// withElision inserts the row marking the entries or items of `b`, or else `a`, elided because of [Dumper.MaxItems].
+func (w *differ) withElision(rows []row, a, b *node) []row {
+	n := b
+	if n.elided == 0 {
+		n = a
+	}
+	if n.elided == 0 {
+		return rows
+	}
+
+	head := min(n.head, len(rows))
+	return append(rows[:head:head], append([]row{{elided: n.elided}}, rows[head:]...)...)
+}
+
+// signature returns the plain text of the node `n`, which identifies map keys.
+func (w *differ) signature(n *node) string {
+	d := w.d
+
+	theme, depth := d.Theme, d.depth
+	d.Theme, d.depth = Theme{}, 0
+	s := d.capture(func() { w.value(' ', n) })
+	d.Theme, d.depth = theme, depth
+
+	return s
+}
+
+// line starts a new line marked with `m`, which is '-', '+' or a space, and indented at the current depth.
+func (w *differ) line(m byte) {
+	d := w.d
+
+	d.buf.WriteString("\n")
+	switch m {
+	case '-':
+		d.buf.WriteString(__(d.Theme.Removed, "-"))
+	case '+':
+		d.buf.WriteString(__(d.Theme.Added, "+"))
+	default:
+		d.buf.WriteByte(m)
+	}
+	d.indent()
+}
+
+// label writes the name or the key of the row `r`, if any, on lines marked with `m`.
+func (w *differ) label(m byte, r row) {
+	switch {
+	case r.name != "":
+		w.d.buf.WriteString(__(w.d.Theme.Fields, r.name) + ": ")
+	case r.key != nil:
+		w.value(m, r.key)
+		w.d.buf.WriteString(": ")
+	}
+}
+
+// open writes the line opening the struct, map or list node `n` of the row `r`, reached through the pointers
+// written as `prefix`, and marked with `m`.
+func (w *differ) open(m byte, r row, prefix string, n *node) {
+	w.line(m)
+	w.label(m, r)
+	w.d.buf.WriteString(prefix + __(w.d.Theme.Types, header(n)) + __(w.d.Theme.Braces, " {") + w.tag(n.id))
+}
+
+// write writes the node `n` of the row `r` in full, on lines marked with `m`.
+func (w *differ) write(m byte, r row, n *node) {
+	w.line(m)
+	w.label(m, r)
+	w.value(m, n)
+	w.d.buf.WriteString(r.suffix)
+}
+
+// value writes the node `n` the way the text format does, its lines after the first one marked with `m`.
+func (w *differ) value(m byte, n *node) {
+	d := w.d
+
+	switch n.kind {
+	case nodeRef:
+		if n.typ.Kind() == reflect.Pointer {
+			d.buf.WriteString(__(d.Theme.Address, "&"))
+		}
+		d.buf.WriteString(__(d.Theme.PointerTag, fmt.Sprintf("@%d", n.id)))
+	case nodePointer:
+		if n.elem.kind != nodeNil || n.elem.typ != nil {
+			d.buf.WriteString(__(d.Theme.Address, "&"))
+		}
+		w.value(m, n.elem)
+	case nodeStruct, nodeMap, nodeList:
+		d.buf.WriteString(__(d.Theme.Types, header(n)) + __(d.Theme.Braces, " {") + w.tag(n.id))
+		if n.folded {
+			summary := ""
+			if n.kind == nodeStruct {
+				summary = fmt.Sprintf("%d fields", d.countFields(n.typ))
+			}
+			if n.id != 0 {
+				d.buf.WriteString(" ")
+			}
+			d.buf.WriteString(__(d.Theme.Elision, "…"+summary) + __(d.Theme.Braces, "}"))
+			return
+		}
+
+		var rows []row
+		switch n.kind {
+		case nodeStruct:
+			rows = w.fields(n, n)
+		case nodeMap:
+			for _, e := range n.entries {
+				rows = append(rows, row{key: e.key, b: e.value, suffix: ","})
+			}
+			rows = w.withElision(rows, n, n)
+		default:
+			for _, item := range n.items {
+				rows = append(rows, row{b: item, suffix: ","})
+			}
+			rows = w.withElision(rows, n, n)
+		}
+
+		d.depth++
+		for _, r := range rows {
+			if r.elided > 0 {
+				w.line(m)
+				d.buf.WriteString(__(d.Theme.Elision, fmt.Sprintf("… %s more …", thousands(r.elided))))
+				continue
+			}
+			w.write(m, r, r.b)
+		}
+		d.depth--
+
+		if len(rows) > 0 {
+			w.line(m)
+		}
+		d.buf.WriteString(__(d.Theme.Braces, "}"))
+	default:
+		d.writeLeaf(n)
+	}
+}
+
+// tag returns the styled pointer tag '#x' of the id `id`, or an empty string if it is zero.
+func (w *differ) tag(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return __(w.d.Theme.PointerTag, fmt.Sprintf("#%d", id))
+}
@@ -0,0 +1,232 @@
+package godump
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// dotWriter writes nodes as a Graphviz DOT graph, see [FormatDOT].
+type dotWriter struct {
+	d *renderer
+
+	// vertices and edges are the statements declaring the vertices of the graph, and the edges between them.
+	vertices, edges []string
+
+	// names are the names of the vertices of values having a pointer id, by id.
+	names map[uint]string
+
+	// walking are the pointer ids of the values being written, references to them are back edges.
+	walking map[uint]bool
+}
+
+// writeDOT writes the node `n` as a directed graph, see [FormatDOT].
+func (d *renderer) writeDOT(n *node) {
+	theme, multiline := d.Theme, d.MultilineStrings
+	d.Theme, d.MultilineStrings = Theme{}, false
+
+	w := dotWriter{d: d, names: make(map[uint]string), walking: make(map[uint]bool)}
+	w.vertex(n)
+	d.Theme, d.MultilineStrings = theme, multiline
+
+	d.buf.WriteString("digraph godump {\n")
+	d.buf.WriteString(d.Indentation + "rankdir=LR;\n")
+	d.buf.WriteString(d.Indentation + `node [shape=record, fontname="monospace"];` + "\n")
+	for _, stmt := range append(w.vertices, w.edges...) {
+		d.buf.WriteString(d.Indentation + stmt + "\n")
+		d.flush()
+	}
+	d.buf.WriteString("}")
+}
+
+// vertex declares the vertex of the node `n`, and returns its name.
+// Structs, maps and lists are record vertices, a row per element, other values are plain boxes.
+func (w *dotWriter) vertex(n *node) string {
+	switch n.kind {
+	case nodeRef:
+		return w.names[n.id]
+	case nodePointer:
+		if n.id == 0 {
+			return w.declare(0, fmt.Sprintf("[shape=box, label=%s]", dotQuote(w.cell(n))))
+		}
+		if e := n.elem; e.id == n.id || e.id == 0 && (e.kind == nodeStruct || e.kind == nodeMap || e.kind == nodeList) {
+			return w.record(e, n.id)
+		}
+
+		name := w.declare(n.id, fmt.Sprintf("[shape=box, label=%s]", dotQuote(n.typ.String()+" #"+fmt.Sprint(n.id))))
+		w.walking[n.id] = true
+		w.edge(name, n.elem)
+		delete(w.walking, n.id)
+		return name
+	case nodeStruct, nodeMap, nodeList:
+		return w.record(n, n.id)
+	default:
+		return w.declare(0, fmt.Sprintf("[shape=box, label=%s]", dotQuote(w.leaf(n))))
+	}
+}
+
+// declare declares a vertex with the attributes `attrs`, and returns its name.
+// The vertex is registered as the one of the pointer id `id` unless it is zero.
+func (w *dotWriter) declare(id uint, attrs string) string {
+	name := fmt.Sprintf("n%d", len(w.vertices)+1)
+	if id != 0 {
+		w.names[id] = name
+	}
+	w.vertices = append(w.vertices, name+" "+attrs+";")
+	return name
+}
+
+// record declares the record vertex of the struct, map or list node `n` having the pointer id `id`,
+// and returns its name. Its first row is its type, followed by a row per field, entry or item, whose values
+// are written in place if they are scalars, or as edges to their own vertices otherwise.
+func (w *dotWriter) record(n *node, id uint) string {
+	i := len(w.vertices)
+	name := w.declare(id, "")
+
+	label := dotEscape(header(n))
+	if id != 0 {
+		label += dotEscape(fmt.Sprintf(" #%d", id))
+		w.walking[id] = true
+	}
+
+	row := func(key, value string) {
+		label += "|{" + key + "|" + value + "}"
+	}
+
+	switch {
+	case n.folded && n.kind == nodeStruct:
+		label += "|" + dotEscape(fmt.Sprintf("…%d fields", w.d.countFields(n.typ)))
+	case n.folded:
+		label += "|…"
+	case n.kind == nodeStruct:
+		for i, f := range n.fields {
+			row(dotEscape(f.name), w.port(name, fmt.Sprintf("v%d", i), f.value))
+		}
+	case n.kind == nodeMap:
+		for i := 0; i <= len(n.entries); i++ {
+			if i == n.head && n.elided > 0 {
+				label += "|" + dotEscape(fmt.Sprintf("… %s more …", thousands(n.elided)))
+			}
+			if i < len(n.entries) {
+				e := n.entries[i]
+				row(w.port(name, fmt.Sprintf("k%d", i), e.key), w.port(name, fmt.Sprintf("v%d", i), e.value))
+			}
+		}
+	default:
+		for i := 0; i <= len(n.items); i++ {
+			if i == n.head && n.elided > 0 {
+				label += "|" + dotEscape(fmt.Sprintf("… %s more …", thousands(n.elided)))
+			}
+			if i < len(n.items) {
+				index := i
+				if n.elided > 0 && i >= n.head {
+					index += n.elided
+				}
+				row(fmt.Sprint(index), w.port(name, fmt.Sprintf("v%d", i), n.items[i]))
+			}
+		}
+	}
+
+	if n.truncated != "" {
+		label += "|" + dotEscape("… truncated ("+n.truncated+")")
+	}
+
+	delete(w.walking, id)
+	w.vertices[i] = fmt.Sprintf("%s [label=\"%s\"];", name, label)
+	return name
+}
+
+// port returns the cell of the node `n` within the record vertex `from`. Values that are not written in place,
+// which are pointers to non-scalars and non-empty structs, maps and lists, are linked from the port `p`
+// of the cell to their vertex.
+func (w *dotWriter) port(from, p string, n *node) string {
+	switch n.kind {
+	case nodePointer:
+		if n.id == 0 {
+			return dotEscape(w.cell(n))
+		}
+	case nodeStruct, nodeMap, nodeList:
+		if n.id == 0 && !n.folded && len(n.fields)+len(n.entries)+len(n.items)+n.elided == 0 && n.truncated == "" {
+			return dotEscape(header(n) + " {}")
+		}
+	case nodeRef:
+	default:
+		return dotEscape(w.cell(n))
+	}
+
+	w.edge(from+":"+p, n)
+
+	text := "•"
+	if n.typ.Kind() == reflect.Pointer {
+		text = "&"
+	}
+	return "<" + p + "> " + text
+}
+
+// edge adds the edge from the vertex, or port, `from` to the vertex of `n`. Values held in place rather than
+// pointed to are dashed edges, and references to values being written are back edges, which do not constrain
+// the layout of the graph.
+func (w *dotWriter) edge(from string, n *node) {
+	var attrs string
+	switch n.kind {
+	case nodeRef:
+		if w.walking[n.id] {
+			attrs = " [constraint=false]"
+		}
+	case nodeStruct, nodeMap, nodeList:
+		attrs = " [style=dashed]"
+	}
+
+	i := len(w.edges)
+	w.edges = append(w.edges, "")
+	w.edges[i] = from + " -> " + w.vertex(n) + attrs + ";"
+}
+
+// cell returns the plain text of the node `n`, which is a scalar or a pointer to one.
+func (w *dotWriter) cell(n *node) string {
+	var prefix string
+	for n.kind == nodePointer {
+		prefix += "&"
+		n = n.elem
+	}
+	return prefix + w.leaf(n)
+}
+
+// leaf returns the plain text of the node `n` the way the text format prints it.
+func (w *dotWriter) leaf(n *node) string {
+	return w.d.capture(func() { w.d.writeLeaf(n) })
+}
+
+// dotEscape escapes the characters of `s` that are special within record labels.
+func dotEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '{', '}', '|', '<', '>':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// dotQuote returns `s` as a quoted DOT string, escaped the way labels require.
+func dotQuote(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '"':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return `"` + b.String() + `"`
+}
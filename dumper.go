@@ -2,10 +2,14 @@ package godump
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -71,6 +75,18 @@ type Theme struct {
 
 	// Braces defines the style used for braces '{}' in structural types.
 	Braces Style
+
+	// Escape defines the style used for escape sequences within strings, eg., '\n' or '\x00'.
+	Escape Style
+
+	// Elision defines the style used for markers of omitted content, eg., folded values and elided items.
+	Elision Style
+
+	// Added defines the style used for the '+' marking the lines of the second value printed by [Dumper.Fdiff].
+	Added Style
+
+	// Removed defines the style used for the '-' marking the lines of the first value printed by [Dumper.Fdiff].
+	Removed Style
 }
 
 // DefaultTheme is the default [Theme] used by [Dump].
@@ -88,6 +104,10 @@ var DefaultTheme = Theme{
 	Chan:          RGB{195, 154, 76},
 	UnsafePointer: RGB{89, 193, 180},
 	Braces:        RGB{185, 86, 86},
+	Escape:        RGB{255, 183, 3},
+	Elision:       RGB{110, 110, 110},
+	Added:         RGB{87, 200, 96},
+	Removed:       RGB{229, 72, 77},
 }
 
 // Dump pretty prints `v` using the default Dumper options and the default theme
@@ -99,7 +119,8 @@ func Dump(v any) error {
 
 // Dumper provides an elegant interface to pretty print any variable of any type in a colored and structured format.
 //
-// The zero value for Dumper is a theme-less Dumper ready to use.
+// The zero value for Dumper is a theme-less Dumper ready to use. Once configured, a Dumper is safe for concurrent use
+// by multiple goroutines, as long as its fields are not modified and no formatter is registered meanwhile.
 type Dumper struct {
 	// Indentation is an optional string used for indentation.
 	// The default value is a string of three spaces.
@@ -111,13 +132,133 @@ type Dumper struct {
 	// HidePrivateFields allows you to optionally hide struct's unexported fields from being printed.
 	HidePrivateFields bool
 
+	// Redact lists the patterns of the names of struct fields and string map keys whose values must not be printed,
+	// eg., [SensitiveNames]. Their type is printed followed by "[REDACTED]" instead, and so are the values of
+	// fields tagged `dump:"redact"`, whatever the patterns are. Redacted values are still compared by [Dumper.Fdiff].
+	Redact []*regexp.Regexp
+
+	// MaxDepth limits how deep structs, maps, slices and arrays are expanded, deeper ones are folded into a one-line summary.
+	// The default value 0 means no limit.
+	MaxDepth uint
+
+	// MaxItems limits how many items of slices, arrays and maps are printed. Only the first and last items are kept,
+	// and a marker telling how many were elided is printed between them. The default value 0 means no limit.
+	MaxItems uint
+
+	// UnsortedMapKeys disables the sorting of map keys, maps are then printed in Go's random iteration order.
+	// By default, keys are sorted so that dumping the same map twice gives the same output.
+	UnsortedMapKeys bool
+
+	// Escaping defines how control characters, quotes and invalid UTF-8 bytes within strings are printed.
+	// The default value is [EscapeQuoted].
+	Escaping Escaping
+
+	// MultilineStrings prints strings containing new lines as a block of lines, aligned with the current indentation.
+	MultilineStrings bool
+
+	// MaxBytes limits how many bytes are printed, roughly. Once they are, structs, maps, slices and arrays stop
+	// printing their elements, a marker telling the output was truncated is printed, and open braces are closed.
+	// Formats other than [FormatText] limit the plain text of the values instead, their syntax is not accounted.
+	// The default value 0 means no limit.
+	MaxBytes uint
+
+	// MaxStringLen limits how many bytes of strings are printed, the number of bytes cut is printed after the string.
+	// The default value 0 means no limit.
+	MaxStringLen uint
+
+	// UseStringer prints values implementing error, [fmt.Stringer] or [fmt.GoStringer] using the result of their method,
+	// annotated with their type, instead of their internal fields.
+	UseStringer bool
+
+	// UseStdFormatters prints common standard library types in a readable form instead of their internal fields.
+	// These are [time.Time], [time.Duration], [math/big.Int], [math/big.Float], [net.IP], [net/netip.Addr], [net/url.URL],
+	// [encoding/json.RawMessage], [reflect.Type] and [regexp.Regexp]. Formatters registered using [Dumper.RegisterFormatter]
+	// take precedence over them.
+	UseStdFormatters bool
+
+	// Bytes defines how byte slices and arrays are printed. The default value is [BytesAsNumbers].
+	Bytes BytesMode
+
+	// ShowRunes prints runes and bytes along with the character they represent, eg., 97 'a',
+	// and slices and arrays of runes as quoted strings. Named types based on them are not affected.
+	ShowRunes bool
+
+	// DiffContext is the number of unchanged fields, entries and items printed around each change by [Dumper.Fdiff].
+	// The others are replaced by a marker telling how many were omitted. The default value 0 prints only the changes.
+	DiffContext uint
+
+	// Format defines the output format. The default value is [FormatText].
+	//
+	// Formats other than [FormatText] follow the same traversal, so [Dumper.MaxDepth], [Dumper.MaxItems],
+	// [Dumper.HidePrivateFields], [Dumper.Redact], [Dumper.UnsortedMapKeys], stringers and formatters apply to them as well.
+	// The theme and the options specific to the text format are ignored.
+	Format Format
+
 	// Theme allows you to define your preferred styling.
 	Theme Theme
 
+	formatters []formatter
+}
+
+// renderer holds the state of a single call to a [Dumper], so that the Dumper itself is only read.
+//
+// It embeds a copy of the Dumper, whose options may be adjusted for the duration of the call, eg., to write
+// a format that has its own theme.
+type renderer struct {
+	Dumper
+
 	buf    bytes.Buffer
 	depth  uint
-	ptrs   map[uintptr]uint
+	ptrs   map[ref]uint
+	ptrID  uint
 	ptrTag uint
+	cycles map[ref]struct{}
+
+	// selections are the entries of the maps that are not all printed, as selected ahead of dumping them.
+	selections map[ref]selection
+
+	// dst is the destination of [Dumper.Stream], the buffer is written to it as it fills up.
+	dst     io.Writer
+	written int64
+
+	// err is the first error writing to `dst`, the traversal stops once it is set and the output is discarded.
+	err error
+
+	// captures is the number of captures in progress, see [renderer.capture].
+	captures int
+
+	// ctx is the context of [Dumper.FprintContext], the traversal stops once it is done.
+	ctx context.Context
+
+	// polls is the number of calls to [renderer.interrupted], and done reports whether it found `ctx` done.
+	polls uint
+	done  bool
+
+	// halted reports whether the traversal stopped, see [renderer.truncated].
+	halted bool
+
+	// size is the size of the tree built for the formats other than [FormatText], see [renderer.used].
+	size int
+
+	// diffing reports whether the tree is built for [Dumper.Fdiff], redacted values then have a fingerprint.
+	// reveal reports whether redacted values are printed, which is the case of fingerprints only.
+	diffing, reveal bool
+}
+
+// newRenderer returns a renderer holding a copy of the options of the Dumper, the default ones being set.
+func (d *Dumper) newRenderer() *renderer {
+	r := &renderer{Dumper: *d}
+	if r.Indentation == "" {
+		r.Indentation = "   "
+	}
+	return r
+}
+
+// ref identifies a pointer, map or slice by the address it refers to.
+type ref struct {
+	kind reflect.Kind
+	addr uintptr
+	len  int
 }
 
 // Print formats `v` and writes the result to standard output.
@@ -134,13 +275,13 @@ func (d *Dumper) Println(v any) error {
 	return d.Fprintln(os.Stdout, v)
 }
 
-// Fprint formats `v` and writes the result to `dst`.
+// Fprint formats `v` and writes the result to `dst`. Large values are better written using [Dumper.Stream].
 //
 // It returns a write error if encountered while writing to `dst`.
 func (d *Dumper) Fprint(dst io.Writer, v any) error {
-	d.init()
-	d.dump(reflect.ValueOf(v))
-	if _, err := d.buf.WriteTo(dst); err != nil {
+	r := d.newRenderer()
+	r.render(v)
+	if _, err := r.buf.WriteTo(dst); err != nil {
 		return fmt.Errorf("dumper error: encountered unexpected write error, %v", err)
 	}
 	return nil
@@ -150,46 +291,216 @@ func (d *Dumper) Fprint(dst io.Writer, v any) error {
 //
 // It returns a write error if encountered while writing to `dst`.
 func (d *Dumper) Fprintln(dst io.Writer, v any) error {
-	d.init()
-	d.dump(reflect.ValueOf(v))
-	d.buf.WriteString("\n")
-	if _, err := d.buf.WriteTo(dst); err != nil {
+	r := d.newRenderer()
+	r.render(v)
+	r.buf.WriteString("\n")
+	if _, err := r.buf.WriteTo(dst); err != nil {
+		return fmt.Errorf("dumper error: encountered unexpected write error, %v", err)
+	}
+	return nil
+}
+
+// FprintContext formats `v` and writes the result to `dst`, like [Dumper.Fprint] does, but stops formatting
+// once `ctx` is done. The output is then truncated the way it is by [Dumper.MaxBytes], and the error of `ctx`
+// is returned.
+//
+// It returns a write error if encountered while writing to `dst`.
+func (d *Dumper) FprintContext(ctx context.Context, dst io.Writer, v any) error {
+	r := d.newRenderer()
+	r.ctx = ctx
+	r.render(v)
+	if _, err := r.buf.WriteTo(dst); err != nil {
 		return fmt.Errorf("dumper error: encountered unexpected write error, %v", err)
 	}
+
+	if r.halted {
+		return ctx.Err()
+	}
 	return nil
 }
 
 // Sprint formats `v` and returns the resulting string.
 func (d *Dumper) Sprint(v any) string {
-	d.init()
-	d.dump(reflect.ValueOf(v))
-	return d.buf.String()
+	r := d.newRenderer()
+	r.render(v)
+	return r.buf.String()
 }
 
 // Sprintln formats `v`, appends a new line, and returns the resulting string.
 func (d *Dumper) Sprintln(v any) string {
-	d.init()
-	d.dump(reflect.ValueOf(v))
-	d.buf.WriteString("\n")
-	return d.buf.String()
+	r := d.newRenderer()
+	r.render(v)
+	r.buf.WriteString("\n")
+	return r.buf.String()
 }
 
-func (d *Dumper) init() {
+// init resets the state of the renderer, and returns the value to be dumped.
+//
+// The value is copied when stringers or formatters are used, so that it's addressable and so are its fields.
+func (d *renderer) init(v any) reflect.Value {
 	d.buf.Reset()
-	d.ptrs = make(map[uintptr]uint)
-	if d.Indentation == "" {
-		d.Indentation = "   "
+	d.ptrs = make(map[ref]uint)
+	d.ptrID = 0
+	d.cycles = make(map[ref]struct{})
+	d.selections = make(map[ref]selection)
+
+	val := reflect.ValueOf(v)
+	if d.usesMethods() && val.IsValid() {
+		val = reflect.New(val.Type()).Elem()
+		val.Set(reflect.ValueOf(v))
+	}
+
+	d.scan(val, make(map[ref]bool), 0)
+	return val
+}
+
+// scan walks `v` ahead of dumping it, and records the maps and slices that contain themselves.
+//
+// Unlike pointers, maps and slices are only tagged when they are part of a cycle, so `visited` tracks whether
+// each of them is still being walked. Pointers are walked only once, just like [Dumper.dumpPointer] does.
+// Values deeper than [Dumper.MaxDepth], and items elided because of [Dumper.MaxItems] or beyond [Dumper.MaxBytes],
+// are not walked since they are never printed. Walking stops once the context of [Dumper.FprintContext] is done.
+// Map entries are walked in no particular order, as cycles do not depend on it, unless some of them are elided.
+func (d *renderer) scan(v reflect.Value, visited map[ref]bool, depth uint) {
+	if d.MaxDepth != 0 && depth > d.MaxDepth || d.interrupted() {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		d.scan(v.Elem(), visited, depth)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+
+		r := ref{kind: reflect.Pointer, addr: uintptr(v.UnsafePointer())}
+		if _, ok := visited[r]; ok {
+			return
+		}
+		visited[r] = false
+		d.scan(v.Elem(), visited, depth)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() && d.HidePrivateFields {
+				continue
+			}
+			d.scan(v.Field(i), visited, depth+1)
+		}
+	case reflect.Array:
+		if mayRefer(v.Type().Elem()) {
+			d.scanItems(v, visited, depth)
+		}
+	case reflect.Slice, reflect.Map:
+		r, ok := refOf(v)
+		if !ok {
+			return
+		}
+
+		if walking, ok := visited[r]; ok {
+			if walking {
+				d.cycles[r] = struct{}{}
+			}
+			return
+		}
+
+		visited[r] = true
+		if v.Kind() == reflect.Slice {
+			if mayRefer(v.Type().Elem()) {
+				d.scanItems(v, visited, depth)
+			}
+		} else if mayRefer(v.Type().Key()) || mayRefer(v.Type().Elem()) {
+			d.scanEntries(v, visited, depth)
+		}
+		visited[r] = false
+	}
+}
+
+// scanItems scans the items of the slice or array `v` that are not elided.
+func (d *renderer) scanItems(v reflect.Value, visited map[ref]bool, depth uint) {
+	length := v.Len()
+	head, skipped := d.elide(length)
+	limit := d.budget()
+	for i, scanned := 0, 0; i < length && scanned != limit; i, scanned = i+1, scanned+1 {
+		if i == head {
+			i += skipped
+			if i >= length {
+				break
+			}
+		}
+		d.scan(v.Index(i), visited, depth+1)
+	}
+}
+
+// scanEntries scans the entries of the map `v` that are not elided.
+func (d *renderer) scanEntries(v reflect.Value, visited map[ref]bool, depth uint) {
+	limit := d.budget()
+	if _, skipped := d.elide(v.Len()); skipped == 0 && (limit < 0 || v.Len() <= limit) {
+		for it := v.MapRange(); it.Next() && !d.interrupted(); {
+			d.scan(it.Key(), visited, depth+1)
+			d.scan(it.Value(), visited, depth+1)
+		}
+		return
+	}
+
+	entries, _, _ := d.mapEntries(v, limit)
+	for _, e := range entries {
+		d.scan(e.key, visited, depth+1)
+		d.scan(e.value, visited, depth+1)
+	}
+}
+
+// refOf returns the [ref] of the map or slice `v`, it reports false when `v` cannot refer to anything.
+func refOf(v reflect.Value) (ref, bool) {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() || v.Len() == 0 {
+			return ref{}, false
+		}
+		return ref{kind: reflect.Map, addr: uintptr(v.UnsafePointer())}, true
+	case reflect.Slice:
+		if v.IsNil() || v.Len() == 0 || v.Type().Elem().Size() == 0 {
+			return ref{}, false
+		}
+		return ref{kind: reflect.Slice, addr: uintptr(v.UnsafePointer()), len: v.Len()}, true
+	default:
+		return ref{}, false
+	}
+}
+
+// mayRefer reports whether values of type `t` may lead to a pointer, map or slice.
+func mayRefer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return true
+	default:
+		return false
 	}
 }
 
-func (d *Dumper) dump(val reflect.Value, ignoreDepth ...bool) {
+func (d *renderer) dump(val reflect.Value, ignoreDepth ...bool) {
+	d.flush()
+
 	if len(ignoreDepth) <= 0 || !ignoreDepth[0] {
 		d.indent()
 	}
 
+	defer d.recoverDump(d.buf.Len(), d.written, d.depth, d.ptrID)
+
+	if f := d.formatter(val); f != nil && d.dumpFormatter(val, f) {
+		return
+	}
+
+	if d.UseStringer {
+		if s, ok := stringer(val); ok {
+			d.dumpStringer(val, s)
+			return
+		}
+	}
+
 	switch val.Kind() {
 	case reflect.String:
-		d.wrapType(val, __(d.Theme.Quotes, `"`)+__(d.Theme.String, val.String())+__(d.Theme.Quotes, `"`))
+		d.wrapType(val, d.quote(val.String()))
 	case reflect.Bool:
 		d.wrapType(val, __(d.Theme.Bool, fmt.Sprintf("%t", val.Bool())))
 	case reflect.Slice, reflect.Array:
@@ -216,8 +527,16 @@ func (d *Dumper) dump(val reflect.Value, ignoreDepth ...bool) {
 	case reflect.Pointer:
 		d.dumpPointer(val)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if d.ShowRunes && val.Type() == runeType {
+			d.buf.WriteString(d.char(val))
+			break
+		}
 		d.wrapType(val, __(d.Theme.Number, fmt.Sprint(val)))
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if d.ShowRunes && val.Type() == byteType {
+			d.buf.WriteString(d.char(val))
+			break
+		}
 		d.wrapType(val, __(d.Theme.Number, fmt.Sprint(val)))
 	case reflect.Float32, reflect.Float64:
 		d.wrapType(val, __(d.Theme.Number, fmt.Sprint(val)))
@@ -239,11 +558,10 @@ func (d *Dumper) dump(val reflect.Value, ignoreDepth ...bool) {
 	}
 }
 
-func (d *Dumper) dumpSlice(v reflect.Value) {
-	var tag string
-	if d.ptrTag != 0 {
-		tag = __(d.Theme.PointerTag, fmt.Sprintf("#%d", d.ptrTag))
-		d.ptrTag = 0
+func (d *renderer) dumpSlice(v reflect.Value) {
+	tag, ok := d.tag(v)
+	if !ok {
+		return
 	}
 
 	length := v.Len()
@@ -260,10 +578,36 @@ func (d *Dumper) dumpSlice(v reflect.Value) {
 		d.buf.WriteString(__(d.Theme.Types, v.Type().String()))
 	}
 
+	if d.Bytes != BytesAsNumbers && isBytes(v) {
+		d.dumpBytes(v, tag)
+		return
+	}
+
+	if d.ShowRunes && isRunes(v) {
+		d.dumpRunes(v, tag)
+		return
+	}
+
 	d.buf.WriteString(__(d.Theme.Braces, fmt.Sprintf(" {%s", tag)))
 
+	if length > 0 && d.fold(tag, "") {
+		return
+	}
+
+	head, skipped := d.elide(length)
+
 	d.depth++
 	for i := 0; i < length; i++ {
+		if d.truncated() {
+			break
+		}
+
+		if i == head && skipped > 0 {
+			d.writeElision(skipped)
+			i += skipped - 1
+			continue
+		}
+
 		d.buf.WriteString("\n")
 		d.dump(v.Index(i))
 		d.buf.WriteString(",")
@@ -278,13 +622,10 @@ func (d *Dumper) dumpSlice(v reflect.Value) {
 	d.buf.WriteString(__(d.Theme.Braces, "}"))
 }
 
-func (d *Dumper) dumpMap(v reflect.Value) {
-	keys := v.MapKeys()
-
-	var tag string
-	if d.ptrTag != 0 {
-		tag = __(d.Theme.PointerTag, fmt.Sprintf("#%d", d.ptrTag))
-		d.ptrTag = 0
+func (d *renderer) dumpMap(v reflect.Value) {
+	tag, ok := d.tag(v)
+	if !ok {
+		return
 	}
 
 	if v.IsNil() {
@@ -294,20 +635,43 @@ func (d *Dumper) dumpMap(v reflect.Value) {
 		return
 	}
 
-	d.buf.WriteString(__(d.Theme.Types, fmt.Sprintf("%s:%d", v.Type(), len(keys))))
+	length := v.Len()
+
+	d.buf.WriteString(__(d.Theme.Types, fmt.Sprintf("%s:%d", v.Type(), length)))
 	d.buf.WriteString(__(d.Theme.Braces, fmt.Sprintf(" {%s", tag)))
 
+	if length > 0 && d.fold(tag, "") {
+		return
+	}
+
+	entries, head, skipped := d.mapEntries(v, d.budget())
+
 	d.depth++
-	for _, key := range keys {
+	for i := 0; i <= len(entries); i++ {
+		if d.truncated() {
+			break
+		}
+
+		if i == head && skipped > 0 {
+			d.writeElision(skipped)
+		}
+		if i == len(entries) {
+			break
+		}
+
 		d.buf.WriteString("\n")
-		d.dump(key)
+		d.dump(entries[i].key)
 		d.buf.WriteString((": "))
-		d.dump(v.MapIndex(key), true)
+		if d.redactsKey(entries[i].key) {
+			d.writeRedacted(redactedType(entries[i].value))
+		} else {
+			d.dump(entries[i].value, true)
+		}
 		d.buf.WriteString((","))
 	}
 	d.depth--
 
-	if len(keys) > 0 {
+	if length > 0 {
 		d.buf.WriteString("\n")
 		d.indent()
 	}
@@ -315,7 +679,83 @@ func (d *Dumper) dumpMap(v reflect.Value) {
 	d.buf.WriteString(__(d.Theme.Braces, "}"))
 }
 
-func (d *Dumper) dumpPointer(v reflect.Value) {
+// mapEntries returns the entries of the map `v` that are printed, sorted by key unless [Dumper.UnsortedMapKeys]
+// is set. The first `head` entries are followed by the last ones, the `skipped` entries between them being elided
+// because of [Dumper.MaxItems]. At most `limit` entries are returned unless it is negative, as each of them takes
+// a byte at least, see [renderer.budget]. Entries left out are not sorted, and none are returned once the context
+// of [Dumper.FprintContext] is done.
+//
+// When some entries are left out, the selection is kept and reused by later calls, so that the map is iterated
+// once, and that the same entries are scanned and printed even though the order maps are iterated in changes.
+//
+// Keys and values are read together from a map iterator, as keys such as NaN cannot be looked up.
+func (d *renderer) mapEntries(v reflect.Value, limit int) (entries []entry, head, skipped int) {
+	length := v.Len()
+	head, skipped = d.elide(length)
+
+	// first and last are the number of leading and trailing entries returned.
+	first, last := head, length-head-skipped
+	if limit >= 0 {
+		first = min(first, limit)
+		last = min(last, limit-first)
+	}
+
+	if d.interrupted() {
+		return nil, head, skipped
+	}
+
+	r, _ := refOf(v)
+	if s, ok := d.selections[r]; ok && (s.limit < 0 || limit >= 0 && limit <= s.limit) {
+		entries = append(s.entries[:first:first], s.entries[s.first:s.first+last]...)
+		return entries, head, skipped
+	}
+
+	switch {
+	case first+last == length:
+		entries = make([]entry, 0, length)
+		for it := v.MapRange(); it.Next() && !d.interrupted(); {
+			entries = append(entries, entry{key: it.Key(), value: it.Value()})
+		}
+
+		if !d.UnsortedMapKeys {
+			sortEntries(entries, d.interrupted)
+		}
+	case d.UnsortedMapKeys:
+		entries = make([]entry, 0, first+last)
+		for i, it := 0, v.MapRange(); len(entries) < first+last && it.Next(); i++ {
+			if i < first || i >= head+skipped {
+				entries = append(entries, entry{key: it.Key(), value: it.Value()})
+			}
+		}
+	default:
+		least := entryHeap{size: first, cmp: compareEntries}
+		greatest := entryHeap{size: length - head - skipped, cmp: func(a, b entry) int { return compareEntries(b, a) }}
+		for it := v.MapRange(); it.Next() && !d.interrupted(); {
+			if e, out := least.push(entry{key: it.Key(), value: it.Value()}); out {
+				greatest.push(e)
+			}
+		}
+
+		tail := greatest.sorted()
+		slices.Reverse(tail)
+		entries = append(least.sorted(), tail[:min(last, len(tail))]...)
+	}
+
+	if first+last < length && !d.done {
+		d.selections[r] = selection{entries: entries, first: first, limit: limit}
+	}
+	return entries, head, skipped
+}
+
+// selection is the entries of a map returned by [renderer.mapEntries] given the limit `limit`,
+// the first `first` of them being the leading ones.
+type selection struct {
+	entries []entry
+	first   int
+	limit   int
+}
+
+func (d *renderer) dumpPointer(v reflect.Value) {
 	if v.IsNil() {
 		d.buf.WriteString(__(d.Theme.Types, v.Type().String()))
 		d.writeNil()
@@ -333,20 +773,82 @@ func (d *Dumper) dumpPointer(v reflect.Value) {
 	}
 
 	d.buf.WriteString(__(d.Theme.Address, "&"))
-	addr := uintptr(v.UnsafePointer())
 
-	if id, ok := d.ptrs[addr]; ok {
+	id, seen := d.pointerID(v)
+	if seen {
 		d.buf.WriteString(__(d.Theme.PointerTag, fmt.Sprintf("@%d", id)))
 		return
 	}
 
-	d.ptrs[addr] = uint(len(d.ptrs) + 1)
-	d.ptrTag = uint(len(d.ptrs))
+	d.ptrTag = id
 	d.dump(elem, true)
 	d.ptrTag = 0
 }
 
-func (d *Dumper) dumpStruct(v reflect.Value) {
+// pointerID returns the id of the non-nil pointer `v`, it reports whether the pointer was already seen.
+// A pointer to a map or slice that was already seen is given the id of that map or slice.
+func (d *renderer) pointerID(v reflect.Value) (id uint, seen bool) {
+	r := ref{kind: reflect.Pointer, addr: uintptr(v.UnsafePointer())}
+
+	id, seen = d.ptrs[r]
+	if !seen {
+		if er, isRef := refOf(v.Elem()); isRef {
+			id, seen = d.ptrs[er]
+		}
+	}
+
+	if seen {
+		d.ptrs[r] = id
+		return id, true
+	}
+
+	d.ptrID++
+	d.ptrs[r] = d.ptrID
+	return d.ptrID, false
+}
+
+// tag returns the pointer tag '#x' to be printed next to the opening brace of the map or slice `v`.
+// If `v` was already printed, its reference '@x' is written instead and ok is false.
+func (d *renderer) tag(v reflect.Value) (tag string, ok bool) {
+	id, seen := d.refID(v)
+	if seen {
+		d.buf.WriteString(__(d.Theme.PointerTag, fmt.Sprintf("@%d", id)))
+		return "", false
+	}
+
+	if id == 0 {
+		return "", true
+	}
+	return __(d.Theme.PointerTag, fmt.Sprintf("#%d", id)), true
+}
+
+// refID returns the id of the map or slice `v`, or 0 if it has none. It reports whether `v` was already seen.
+//
+// Maps and slices that contain themselves are given an id of their own, or share the id of the pointer
+// they were reached through.
+func (d *renderer) refID(v reflect.Value) (id uint, seen bool) {
+	id = d.ptrTag
+	d.ptrTag = 0
+
+	if r, isRef := refOf(v); isRef {
+		if _, cyclic := d.cycles[r]; cyclic {
+			if ref, seen := d.ptrs[r]; seen {
+				return ref, true
+			}
+
+			if id == 0 {
+				d.ptrID++
+				id = d.ptrID
+			}
+			d.ptrs[r] = id
+		}
+	}
+
+	return id, false
+}
+
+func (d *renderer) dumpStruct(v reflect.Value) {
+	v = d.addressable(v)
 	vtype := v.Type()
 
 	var tag string
@@ -363,6 +865,10 @@ func (d *Dumper) dumpStruct(v reflect.Value) {
 	d.buf.WriteString(__(d.Theme.Braces, " {"))
 	d.buf.WriteString(__(d.Theme.PointerTag, tag))
 
+	if fields := d.countFields(vtype); fields > 0 && d.fold(tag, fmt.Sprintf("%d fields", fields)) {
+		return
+	}
+
 	var hasFields bool
 
 	d.depth++
@@ -373,13 +879,20 @@ func (d *Dumper) dumpStruct(v reflect.Value) {
 		}
 
 		hasFields = true
+		if d.truncated() {
+			break
+		}
 
 		d.buf.WriteString("\n")
 		d.indent()
 
 		d.buf.WriteString(__(d.Theme.Fields, key.Name))
 		d.buf.WriteString((": "))
-		d.dump(v.Field(i), true)
+		if d.redactsField(key) {
+			d.writeRedacted(redactedType(v.Field(i)))
+		} else {
+			d.dump(fieldOf(v, i), true)
+		}
 		d.buf.WriteString((","))
 	}
 	d.depth--
@@ -392,7 +905,136 @@ func (d *Dumper) dumpStruct(v reflect.Value) {
 	d.buf.WriteString(__(d.Theme.Braces, "}"))
 }
 
-func (d *Dumper) indent() {
+// fold writes the summary of a structural value in place of its content, and closes its braces.
+// It reports whether the value was folded, which happens when it is deeper than [Dumper.MaxDepth].
+func (d *renderer) fold(tag, summary string) bool {
+	if !d.tooDeep() {
+		return false
+	}
+
+	if tag != "" {
+		d.buf.WriteString(" ")
+	}
+	d.buf.WriteString(__(d.Theme.Elision, "…"+summary) + __(d.Theme.Braces, "}"))
+	return true
+}
+
+// tooDeep reports whether the content of values at the current depth is beyond [Dumper.MaxDepth].
+func (d *renderer) tooDeep() bool {
+	return d.MaxDepth != 0 && d.depth >= d.MaxDepth
+}
+
+// elide returns the number of the leading items printed out of the `n` items of a collection,
+// and the number of items elided right after them in order to respect [Dumper.MaxItems].
+func (d *renderer) elide(n int) (head, skipped int) {
+	if d.MaxItems == 0 || n <= int(d.MaxItems) {
+		return n, 0
+	}
+	return int(d.MaxItems+1) / 2, n - int(d.MaxItems)
+}
+
+// budget returns the number of bytes left before [Dumper.MaxBytes] are written, or -1 if there is no limit.
+// It is an upper bound of the number of values that can still be printed.
+func (d *renderer) budget() int {
+	if d.MaxBytes == 0 {
+		return -1
+	}
+	return max(0, int(d.MaxBytes)-d.used())
+}
+
+// used returns the number of bytes [Dumper.MaxBytes] are compared to. With [FormatText], these are the bytes
+// written so far. Other formats are written once their tree is built, so it is the size of the tree instead,
+// which is the plain text of its values and the names of its fields, plus a byte per node.
+func (d *renderer) used() int {
+	if d.Format != FormatText {
+		return d.size
+	}
+	return int(d.written) + d.buf.Len()
+}
+
+// interrupted reports whether the context of [Dumper.FprintContext] is done, or whether writing the output of
+// [Dumper.Stream] failed. It is called for each value walked ahead of dumping and each comparison of map keys,
+// so the context is only checked every so often.
+func (d *renderer) interrupted() bool {
+	if d.err != nil {
+		return true
+	}
+	if d.ctx == nil || d.done {
+		return d.done
+	}
+
+	if d.polls%256 == 0 && d.ctx.Err() != nil {
+		d.done = true
+	}
+	d.polls++
+	return d.done
+}
+
+// truncated reports whether the traversal is to stop, which is when the context of [Dumper.FprintContext] is done
+// or [Dumper.MaxBytes] are written. The first time it does, the marker of the truncation is written on its own line.
+func (d *renderer) truncated() bool {
+	if d.halted {
+		return true
+	}
+
+	reason := d.stopReason()
+	if reason == "" {
+		return false
+	}
+
+	d.halted = true
+	d.buf.WriteString("\n")
+	d.indent()
+	d.buf.WriteString(__(d.Theme.Elision, "… truncated ("+reason+")"))
+	return true
+}
+
+// stopReason returns the reason why the traversal is to stop, or an empty string if it is not.
+func (d *renderer) stopReason() string {
+	switch {
+	case d.err != nil:
+		return "write error"
+	case d.ctx != nil && d.ctx.Err() != nil:
+		return d.ctx.Err().Error()
+	case d.MaxBytes > 0 && d.used() >= int(d.MaxBytes):
+		return "budget exceeded"
+	default:
+		return ""
+	}
+}
+
+// writeElision writes the marker of `n` elided items on its own line.
+func (d *renderer) writeElision(n int) {
+	d.buf.WriteString("\n")
+	d.indent()
+	d.buf.WriteString(__(d.Theme.Elision, fmt.Sprintf("… %s more …", thousands(n))))
+}
+
+// thousands formats `n` with commas as thousands separators, eg., 9,990.
+func thousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// countFields returns the number of fields of the struct type `t` that are to be printed.
+func (d *renderer) countFields(t reflect.Type) int {
+	if !d.HidePrivateFields {
+		return t.NumField()
+	}
+
+	var n int
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			n++
+		}
+	}
+	return n
+}
+
+func (d *renderer) indent() {
 	d.buf.WriteString(strings.Repeat(d.Indentation, int(d.depth)))
 }
 
@@ -413,7 +1055,7 @@ func isPrimitive(val reflect.Value) bool {
 	}
 }
 
-func (d *Dumper) wrapType(v reflect.Value, str string) {
+func (d *renderer) wrapType(v reflect.Value, str string) {
 	if d.ShowPrimitiveNamedTypes {
 		if t := v.Type(); t.PkgPath() != "" {
 			str = __(d.Theme.Types, t.String()) + __(d.Theme.Braces, "(") + str + __(d.Theme.Braces, ")")
@@ -423,6 +1065,47 @@ func (d *Dumper) wrapType(v reflect.Value, str string) {
 	d.buf.WriteString(str)
 }
 
-func (d *Dumper) writeNil() {
+// recoverDump recovers from a panic while dumping a value, whose output started at `start` in the buffer once
+// `written` bytes were flushed, at depth `depth`, the last pointer id given being `ptrID`. The output of the value
+// is replaced by a placeholder, and the ids given within it are forgotten, unless part of it was flushed already,
+// in which case the placeholder follows it.
+func (d *renderer) recoverDump(start int, written int64, depth, ptrID uint) {
+	e := recover()
+	if e == nil {
+		return
+	}
+
+	d.depth, d.ptrTag = depth, 0
+	if d.written == written {
+		d.buf.Truncate(start)
+		d.forget(ptrID)
+	} else {
+		d.buf.WriteString(" ")
+	}
+	d.writePanic(panicMessage(e))
+}
+
+// forget forgets the pointer ids given after `id`, as the values having them are not printed.
+func (d *renderer) forget(id uint) {
+	for r, i := range d.ptrs {
+		if i > id {
+			delete(d.ptrs, r)
+		}
+	}
+	d.ptrID = id
+}
+
+// panicMessage returns the value `e` a panic was called with as a single line of text.
+func panicMessage(e any) string {
+	s := strconv.Quote(fmt.Sprint(e))
+	return strings.ReplaceAll(s[1:len(s)-1], `\"`, `"`)
+}
+
+// writePanic writes the placeholder of a value whose traversal panicked with the message `msg`.
+func (d *renderer) writePanic(msg string) {
+	d.buf.WriteString(__(d.Theme.Nil, "<panic: "+msg+">"))
+}
+
+func (d *renderer) writeNil() {
 	d.buf.WriteString(__(d.Theme.Braces, "(") + __(d.Theme.Nil, "nil") + __(d.Theme.Braces, ")"))
 }
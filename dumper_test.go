@@ -2,9 +2,23 @@ package godump_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/parser"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/yassinebenaid/godump"
@@ -791,6 +805,1684 @@ func TestCanDumpMaps(t *testing.T) {
 	checkFromFeed(t, []byte(result), "./testdata/maps.txt")
 }
 
+func TestCanDumpCyclicMapsAndSlices(t *testing.T) {
+	type Map map[string]Map
+	type Slice []Slice
+	type Node struct {
+		Name     string
+		Children []any
+	}
+
+	m := map[string]any{}
+	m["self"] = m
+
+	s := []any{"first", nil}
+	s[1] = s
+
+	named := Map{}
+	named["self"] = named
+
+	nested := Slice{nil}
+	nested[0] = nested
+
+	node := Node{Name: "node"}
+	node.Children = []any{map[string]any{"node": &node}, nil}
+	node.Children[1] = node.Children
+
+	var d godump.Dumper
+	result := d.Sprint([]any{m, s, &s, named, nested, node})
+
+	checkFromFeed(t, []byte(result), "./testdata/cycles.txt")
+}
+
+func TestCanLimitDepth(t *testing.T) {
+	type Child struct {
+		Name     string
+		Age      int
+		Parent   *Child
+		private  bool
+		Empty    struct{}
+		Children []Child
+	}
+
+	type Node struct {
+		Child  Child
+		Ptr    *Child
+		Array  [2]int
+		Map    map[string][]int
+		Nested map[string]any
+		Empty  []int
+	}
+
+	child := Child{
+		Name:     "foo",
+		Children: []Child{{Name: "bar"}},
+	}
+	child.Parent = &child
+
+	node := Node{
+		Child:  child,
+		Ptr:    &child,
+		Map:    map[string][]int{"foo": make([]int, 500, 512)},
+		Nested: map[string]any{"foo": map[string]int{"bar": 1}},
+		Empty:  []int{},
+	}
+
+	d := godump.Dumper{MaxDepth: 2}
+	result := d.Sprint(node)
+
+	checkFromFeed(t, []byte(result), "./testdata/max-depth.txt")
+
+	d.HidePrivateFields = true
+	if r := d.Sprint([]any{[]any{child}}); r != `[]interface {}:1:1 {
+   []interface {}:1:1 {
+      godump_test.Child {…5 fields},
+   },
+}` {
+		t.Fatalf("unexpected result when dumping beyond the max depth with hide private fields option enabled: `%s`", r)
+	}
+}
+
+func TestCanLimitItems(t *testing.T) {
+	type Node struct {
+		Buffer []int
+		Array  [4]string
+		Map    map[string]int
+		Short  []int
+	}
+
+	buffer := make([]int, 10000)
+	for i := range buffer {
+		buffer[i] = i
+	}
+
+	node := Node{
+		Buffer: buffer,
+		Array:  [4]string{"a", "b", "c", "d"},
+		Map:    map[string]int{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7},
+		Short:  []int{1, 2, 3},
+	}
+
+	d := godump.Dumper{MaxItems: 3}
+	result := d.Sprint(node)
+
+	expected := `godump_test.Node {
+   Buffer: []int:10000:10000 {
+      0,
+      1,
+      … 9,997 more …
+      9999,
+   },
+   Array: [4]string {
+      "a",
+      "b",
+      … 1 more …
+      "d",
+   },
+   Map: map[string]int:7 {
+      "a": 1,
+      "b": 2,
+      … 4 more …
+      "g": 7,
+   },
+   Short: []int:3:3 {
+      1,
+      2,
+      3,
+   },
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping with a max number of items: `%s`", result)
+	}
+
+	cyclic := map[string]any{"a": 1, "b": 2, "d": 4, "e": 5}
+	cyclic["c"], cyclic["z"] = cyclic, map[string]any{"cyclic": cyclic}
+
+	result = d.Sprint(cyclic)
+	expected = `map[string]interface {}:6 {#1
+   "a": 1,
+   "b": 2,
+   … 3 more …
+   "z": map[string]interface {}:1 {
+      "cyclic": @1,
+   },
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping a cyclic map with a max number of items: `%s`", result)
+	}
+}
+
+func TestCanSortMapKeys(t *testing.T) {
+	type Key struct {
+		Name string
+		ID   int
+	}
+
+	maps := []any{
+		map[int]string{10: "ten", -1: "minus one", 2: "two", 0: "zero"},
+		map[float64]string{2.5: "two and half", math.Inf(-1): "-inf", -1.5: "minus one and half", math.Inf(1): "inf"},
+		map[string]int{"b": 2, "a": 1, "ab": 12, "B": 0, "": -1},
+		map[bool]string{true: "true", false: "false"},
+		map[Key]bool{{"b", 1}: true, {"a", 2}: true, {"a", 1}: true},
+		map[[2]uint]bool{{2, 1}: true, {1, 2}: true, {1, 1}: true},
+		map[any]string{"foo": "string", 3: "int", 1.5: "float", uint8(2): "uint8", nil: "nil", false: "bool", Key{}: "struct"},
+	}
+
+	var d godump.Dumper
+	result := d.Sprint(maps)
+
+	checkFromFeed(t, []byte(result), "./testdata/sorted-maps.txt")
+
+	for i := 0; i < 10; i++ {
+		if r := d.Sprint(maps); r != result {
+			t.Fatalf("unexpected result when dumping the same maps again: `%s`", r)
+		}
+	}
+
+	type Ring struct {
+		Name string
+		Next *Ring
+	}
+
+	first, second := &Ring{Name: "b"}, &Ring{Name: "a"}
+	first.Next, second.Next = first, second
+	pointers := map[*Ring]int{first: 2, second: 1, {Name: "b", Next: second}: 3}
+
+	result = d.Sprint(pointers)
+	expected := `map[*godump_test.Ring]int:3 {
+   &godump_test.Ring {#1
+      Name: "a",
+      Next: &@1,
+   }: 1,
+   &godump_test.Ring {#2
+      Name: "b",
+      Next: &@1,
+   }: 3,
+   &godump_test.Ring {#3
+      Name: "b",
+      Next: &@3,
+   }: 2,
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping a map with pointer keys: `%s`", result)
+	}
+}
+
+func TestCanDumpMapsWithNaNKeys(t *testing.T) {
+	m := map[float64]string{1: "one"}
+	m[math.NaN()] = "nan"
+	m[math.NaN()] = "nan"
+
+	var d godump.Dumper
+	result := d.Sprint(m)
+
+	expected := `map[float64]string:3 {
+   NaN: "nan",
+   NaN: "nan",
+   1: "one",
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping a map with NaN keys: `%s`", result)
+	}
+
+	numbers := map[float64]int{1: 1}
+	for i := 5; i >= 2; i-- {
+		numbers[math.NaN()] = i
+	}
+
+	expected = `map[float64]int:5 {
+   NaN: 2,
+   NaN: 3,
+   NaN: 4,
+   NaN: 5,
+   1: 1,
+}`
+
+	for i := 0; i < 50; i++ {
+		if result := d.Sprint(numbers); result != expected {
+			t.Fatalf("unexpected result when dumping a map with NaN keys repeatedly: `%s`", result)
+		}
+	}
+}
+
+type Brackets struct{}
+
+func (Brackets) Apply(s string) string {
+	return "[" + s + "]"
+}
+
+func TestCanEscapeStrings(t *testing.T) {
+	s := "line 1\nline 2\r\n\ttab \"quoted\" \\ \x1b[31mred\x00 \xff\xfe héllo \u200b"
+
+	cases := []struct {
+		escaping godump.Escaping
+		expected string
+	}{
+		{godump.EscapeQuoted, `"line 1\nline 2\r\n\ttab \"quoted\" \\ \x1b[31mred\x00 \xff\xfe héllo \u200b"`},
+		{godump.EscapeVisible, `"line 1␊line 2␍␊␉tab "quoted" \ ␛[31mred␀ \xff\xfe héllo \u200b"`},
+		{godump.EscapeNone, `"` + s + `"`},
+	}
+
+	for _, c := range cases {
+		d := godump.Dumper{Escaping: c.escaping}
+		if r := d.Sprint(s); r != c.expected {
+			t.Fatalf("unexpected result when dumping a string with escaping mode %d: `%s`", c.escaping, r)
+		}
+	}
+
+	d := godump.Dumper{Theme: godump.Theme{Escape: Brackets{}}}
+	if r := d.Sprint("foo\tbar\xff"); r != `"foo[\t]bar[\xff]"` {
+		t.Fatalf("unexpected result when dumping a string with styled escape sequences: `%s`", r)
+	}
+}
+
+func TestCanDumpMultilineStrings(t *testing.T) {
+	type Request struct {
+		Method  string
+		Query   string
+		Headers map[string]string
+		Body    []string
+	}
+
+	req := Request{
+		Method: "POST",
+		Query:  "SELECT *\nFROM \"users\"\n\tWHERE id = 1\r\n",
+		Headers: map[string]string{
+			"X-Multiline": "foo\nbar",
+		},
+		Body: []string{"line 1\n\nline 3"},
+	}
+
+	d := godump.Dumper{MultilineStrings: true}
+	result := d.Sprint(req)
+
+	checkFromFeed(t, []byte(result), "./testdata/multiline-strings.txt")
+}
+
+func TestCanLimitStringLength(t *testing.T) {
+	d := godump.Dumper{MaxStringLen: 5}
+
+	cases := map[string]string{
+		"foo":                    `"foo"`,
+		"hello":                  `"hello"`,
+		"hello world":            `"hello" … 6 more bytes`,
+		"abcdé":                  `"abcd" … 2 more bytes`,
+		"hello!":                 `"hello" … 1 more byte`,
+		strings.Repeat("a", 1e4): `"aaaaa" … 9,995 more bytes`,
+	}
+
+	for s, expected := range cases {
+		if r := d.Sprint(s); r != expected {
+			t.Fatalf("unexpected result when dumping a string with a max length: `%s`", r)
+		}
+	}
+
+	d.MultilineStrings = true
+	if r := d.Sprint("foo\nbar\nbaz"); r != "\"\"\"\n│ foo\n│ b\n\"\"\" … 6 more bytes" {
+		t.Fatalf("unexpected result when dumping a multiline string with a max length: `%s`", r)
+	}
+}
+
+type ID [4]byte
+
+func (id ID) String() string {
+	return fmt.Sprintf("id-%x", id[:])
+}
+
+type Temperature float64
+
+func (t *Temperature) String() string {
+	return fmt.Sprintf("%.1f°C", float64(*t))
+}
+
+type Panicking struct {
+	Reason string
+}
+
+func (p Panicking) String() string {
+	panic(p.Reason)
+}
+
+type GoStringer struct {
+	X, Y int
+}
+
+func (g GoStringer) GoString() string {
+	return fmt.Sprintf("Point(%d, %d)", g.X, g.Y)
+}
+
+func TestCanDumpStringers(t *testing.T) {
+	type Node struct {
+		Time        time.Time
+		URL         *url.URL
+		ID          ID
+		id          ID
+		Temperature Temperature
+		Err         error
+		NilErr      error
+		NilURL      *url.URL
+		Panicking   Panicking
+		GoStringer  GoStringer
+		IDs         map[ID]any
+	}
+
+	u, _ := url.Parse("https://example.com/path?query=1")
+
+	node := Node{
+		Time:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		URL:         u,
+		ID:          ID{1, 2, 3, 4},
+		id:          ID{5, 6, 7, 8},
+		Temperature: 21.5,
+		Err:         errors.New("something went wrong"),
+		Panicking:   Panicking{Reason: "boom"},
+		GoStringer:  GoStringer{X: 1, Y: 2},
+		IDs: map[ID]any{
+			{9, 9, 9, 9}: ID{0, 0, 0, 0},
+		},
+	}
+
+	d := godump.Dumper{UseStringer: true}
+	result := d.Sprint(node)
+
+	checkFromFeed(t, []byte(result), "./testdata/stringers.txt")
+}
+
+func TestCanDumpStringersOfUnexportedFields(t *testing.T) {
+	type Event struct {
+		at time.Time
+	}
+
+	type Config struct {
+		times  map[string]time.Time
+		id     any
+		events map[string]Event
+	}
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	config := Config{
+		times:  map[string]time.Time{"start": at},
+		id:     ID{1, 2, 3, 4},
+		events: map[string]Event{"login": {at: at}},
+	}
+
+	d := godump.Dumper{UseStringer: true}
+	expected := `godump_test.Config {
+   times: map[string]time.Time:1 {
+      "start": time.Time("2024-01-02 03:04:05 +0000 UTC"),
+   },
+   id: godump_test.ID("id-01020304"),
+   events: map[string]godump_test.Event:1 {
+      "login": godump_test.Event {
+         at: time.Time("2024-01-02 03:04:05 +0000 UTC"),
+      },
+   },
+}`
+
+	if result := d.Sprint(config); result != expected {
+		t.Fatalf("unexpected result when dumping stringers of unexported fields: `%s`", result)
+	}
+
+	d.Format = godump.FormatJSON
+	if result := d.Sprint(config); strings.Contains(result, "wall") || !strings.Contains(result, "id-01020304") {
+		t.Fatalf("unexpected result when dumping stringers of unexported fields as JSON: `%s`", result)
+	}
+}
+
+type Money struct {
+	cents    int64
+	currency string
+}
+
+type Identifier interface {
+	Identify() string
+}
+
+type UserID int
+
+func (id UserID) Identify() string {
+	return fmt.Sprintf("user-%d", int(id))
+}
+
+func TestCanRegisterFormatters(t *testing.T) {
+	type Invoice struct {
+		ID     UserID
+		Total  Money
+		Lines  []Money
+		Owner  Identifier
+		price  Money
+		Nested map[string]any
+	}
+
+	invoice := Invoice{
+		ID:    1,
+		Total: Money{cents: 1250, currency: "EUR"},
+		Lines: []Money{{cents: 1000, currency: "EUR"}},
+		Owner: UserID(2),
+		price: Money{cents: 99, currency: "USD"},
+		Nested: map[string]any{
+			"id": UserID(3),
+		},
+	}
+
+	d := godump.Dumper{Theme: godump.Theme{Number: Brackets{}}}
+	d.RegisterFormatter(reflect.TypeOf(Money{}), func(w *godump.Writer, v any) {
+		m := v.(Money)
+		w.Write(w.Theme.Number, fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100))
+		w.Write(nil, " "+m.currency)
+	})
+	d.RegisterFormatter(reflect.TypeOf((*Identifier)(nil)).Elem(), func(w *godump.Writer, v any) {
+		w.Write(nil, "Identifier {")
+		w.Indent()
+		w.Newline()
+		w.Write(nil, "Value: ")
+		w.Dump(v.(Identifier).Identify())
+		w.Write(nil, ",")
+		w.Dedent()
+		w.Newline()
+		w.Write(nil, "}")
+	})
+
+	expected := `godump_test.Invoice {
+   ID: Identifier {
+      Value: "user-1",
+   },
+   Total: [12.50] EUR,
+   Lines: []godump_test.Money:1:1 {
+      [10.00] EUR,
+   },
+   Owner: Identifier {
+      Value: "user-2",
+   },
+   price: [0.99] USD,
+   Nested: map[string]interface {}:1 {
+      "id": Identifier {
+         Value: "user-3",
+      },
+   },
+}`
+
+	if r := d.Sprint(invoice); r != expected {
+		t.Fatalf("unexpected result when dumping values with registered formatters: `%s`", r)
+	}
+
+	d.RegisterFormatter(reflect.TypeOf(Money{}), func(w *godump.Writer, _ any) {
+		w.Write(nil, "money")
+	})
+
+	if r := d.Sprint(invoice.Lines); r != "[]godump_test.Money:1:1 {\n   money,\n}" {
+		t.Fatalf("unexpected result when replacing a registered formatter: `%s`", r)
+	}
+
+	type Ledger struct {
+		totals  map[string]Money
+		owner   any
+		entries map[int]Invoice
+	}
+
+	ledger := Ledger{
+		totals:  map[string]Money{"EUR": {cents: 1250, currency: "EUR"}},
+		owner:   UserID(4),
+		entries: map[int]Invoice{1: {price: Money{cents: 99, currency: "USD"}}},
+	}
+
+	expected = `godump_test.Ledger {
+   totals: map[string]godump_test.Money:1 {
+      "EUR": money,
+   },
+   owner: Identifier {
+      Value: "user-4",
+   },
+   entries: map[int]godump_test.Invoice:1 {
+      [1]: godump_test.Invoice {
+         ID: Identifier {
+            Value: "user-0",
+         },
+         Total: money,
+         Lines: []godump_test.Money(nil),
+         Owner: nil,
+         price: money,
+         Nested: map[string]interface {}(nil),
+      },
+   },
+}`
+
+	if r := d.Sprint(ledger); r != expected {
+		t.Fatalf("unexpected result when dumping values of unexported fields with registered formatters: `%s`", r)
+	}
+}
+
+func TestCanDumpStandardLibraryTypes(t *testing.T) {
+	type Node struct {
+		Time       time.Time
+		Duration   time.Duration
+		BigInt     *big.Int
+		BigFloat   big.Float
+		IP         net.IP
+		Addr       netip.Addr
+		URL        *url.URL
+		RawMessage json.RawMessage
+		Invalid    json.RawMessage
+		Type       reflect.Type
+		Regexp     *regexp.Regexp
+		timeout    time.Duration
+		NilURL     *url.URL
+	}
+
+	bigInt, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	u, _ := url.Parse("https://example.com/path?query=1")
+
+	node := Node{
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 600, time.FixedZone("CET", 3600)),
+		Duration:   1500 * time.Millisecond,
+		BigInt:     bigInt,
+		BigFloat:   *big.NewFloat(1.5),
+		IP:         net.IPv4(192, 168, 0, 1),
+		Addr:       netip.MustParseAddr("::1"),
+		URL:        u,
+		RawMessage: json.RawMessage(`{"foo": [1, 2]}`),
+		Invalid:    json.RawMessage(`{"foo`),
+		Type:       reflect.TypeOf(map[string]int{}),
+		Regexp:     regexp.MustCompile(`^a+\d$`),
+		timeout:    time.Minute,
+	}
+
+	d := godump.Dumper{UseStdFormatters: true}
+	result := d.Sprint(node)
+
+	checkFromFeed(t, []byte(result), "./testdata/std-types.txt")
+
+	type Server struct {
+		started map[string]time.Time
+		url     any
+	}
+
+	server := Server{
+		started: map[string]time.Time{"api": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		url:     u,
+	}
+
+	expected := `godump_test.Server {
+   started: map[string]time.Time:1 {
+      "api": time.Time("2024-01-02T03:04:05Z UTC"),
+   },
+   url: &url.URL("https://example.com/path?query=1"),
+}`
+
+	if r := d.Sprint(server); r != expected {
+		t.Fatalf("unexpected result when dumping standard library types held by unexported fields: `%s`", r)
+	}
+
+	d.RegisterFormatter(reflect.TypeOf(time.Duration(0)), func(w *godump.Writer, v any) {
+		w.Write(nil, fmt.Sprintf("%.0fs", v.(time.Duration).Seconds()))
+	})
+
+	if r := d.Sprint(time.Minute); r != "60s" {
+		t.Fatalf("unexpected result when overriding the formatter of a standard library type: `%s`", r)
+	}
+}
+
+func TestCanDumpBytes(t *testing.T) {
+	type Packet struct {
+		Payload []byte
+		Header  [4]byte
+		Text    []byte
+		Empty   []byte
+		Nil     []byte
+	}
+
+	payload := make([]byte, 0, 64)
+	payload = append(payload, "Hello, World!\n"...)
+	for i := 0; i < 20; i++ {
+		payload = append(payload, byte(i*13))
+	}
+
+	packet := Packet{
+		Payload: payload,
+		Header:  [4]byte{0xca, 0xfe, 'G', 'o'},
+		Text:    []byte("héllo \"world\""),
+		Empty:   []byte{},
+	}
+
+	var results []string
+	for _, mode := range []godump.BytesMode{godump.BytesAsHex, godump.BytesAsText} {
+		d := godump.Dumper{Bytes: mode}
+		results = append(results, d.Sprint(packet))
+	}
+
+	checkFromFeed(t, []byte(strings.Join(results, "\n")), "./testdata/bytes.txt")
+
+	d := godump.Dumper{Bytes: godump.BytesAsHex, MaxItems: 2}
+	if r := d.Sprint(make([]byte, 100)); r != `[]uint8:100:100 {
+   00000000  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+   … 5 more …
+   00000060  00 00 00 00                                       |....|
+}` {
+		t.Fatalf("unexpected result when dumping a hex dump with a max number of items: `%s`", r)
+	}
+}
+
+func TestCanDumpRunes(t *testing.T) {
+	type Kind rune
+
+	type Token struct {
+		Start   rune
+		Emoji   rune
+		Quote   rune
+		Newline rune
+		Invalid rune
+		Byte    byte
+		High    byte
+		Kind    Kind
+		Text    []rune
+		Broken  []rune
+		Array   [2]rune
+	}
+
+	token := Token{
+		Start:   'a',
+		Emoji:   '😀',
+		Quote:   '\'',
+		Newline: '\n',
+		Invalid: 0xD800,
+		Byte:    '"',
+		High:    0xc8,
+		Kind:    'k',
+		Text:    []rune("héllo \"😀\"\n"),
+		Broken:  []rune{'o', 'k', 0xD800, '!', 0x110000},
+		Array:   [2]rune{'g', 'o'},
+	}
+
+	d := godump.Dumper{ShowRunes: true}
+	result := d.Sprint(token)
+
+	expected := `godump_test.Token {
+   Start: 97 'a',
+   Emoji: 0x1F600 '😀',
+   Quote: 39 '\'',
+   Newline: 10 '\n',
+   Invalid: 0xD800 '\ud800',
+   Byte: 34 '"',
+   High: 200 '\xc8',
+   Kind: 107,
+   Text: []int32:10:10("héllo \"😀\"\n"),
+   Broken: []int32:5:5("ok\ud800!\U00110000"),
+   Array: [2]int32("go"),
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping runes: `%s`", result)
+	}
+}
+
+func TestCanDumpJSON(t *testing.T) {
+	type Node struct {
+		Name     string
+		Next     *Node
+		Labels   map[string]int
+		Weights  []float64
+		Callback func(int) error
+		Events   chan string
+		private  *int
+	}
+
+	answer := 42
+	first := &Node{
+		Name:     "first \"node\"\n\x00",
+		Labels:   map[string]int{"$id": 1, "b": 2},
+		Weights:  []float64{1.5, math.Inf(1), math.NaN()},
+		Callback: func(int) error { return nil },
+		Events:   make(chan string, 3),
+		private:  &answer,
+	}
+	first.Next = &Node{Name: "second", Next: first}
+
+	cyclic := map[any]any{1: "one", true: []int{}}
+	cyclic["self"] = cyclic
+
+	shared := []string{"a", "b"}
+
+	v := map[string]any{
+		"node":    first,
+		"cyclic":  cyclic,
+		"keys":    map[[2]int]bool{{1, 2}: true},
+		"numbers": []any{int8(-1), uint64(math.MaxUint64), float32(0.1), 1 + 2i, uintptr(0xff)},
+		"shared":  []*[]string{&shared, &shared},
+		"time":    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"error":   errors.New("oops"),
+		"nil":     nil,
+		"nils":    []any{(*int)(nil), []int(nil), map[int]int(nil), (func())(nil), unsafe.Pointer(nil)},
+		"array":   [2]bool{true, false},
+	}
+
+	d := godump.Dumper{Format: godump.FormatJSON, UseStdFormatters: true}
+	result := d.Sprint(v)
+
+	if !json.Valid([]byte(result)) {
+		t.Fatalf("invalid JSON: `%s`", result)
+	}
+
+	checkFromFeed(t, []byte(result), "./testdata/json.txt")
+
+	d = godump.Dumper{Format: godump.FormatJSON, MaxDepth: 2, MaxItems: 2}
+	result = d.Sprint(map[string]any{
+		"list":   []int{1, 2, 3, 4, 5},
+		"map":    map[int]string{1: "a", 2: "b", 3: "c"},
+		"folded": []any{[]int{1}},
+	})
+
+	expected := `{
+   "$type": "map[string]interface {}",
+   "folded": [
+      {
+         "$type": "[]int",
+         "$folded": true
+      }
+   ],
+   "$elided": 1,
+   "map": {
+      "$type": "map[int]string",
+      "1": "a",
+      "$elided": 1,
+      "3": "c"
+   }
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping JSON with a max depth and items: `%s`", result)
+	}
+}
+
+func TestCanDumpYAML(t *testing.T) {
+	type Service struct {
+		Name     string
+		Replicas int
+		Script   string
+		Env      map[string]string
+		Ports    []uint16
+		Parent   *Service
+		Ratio    float64
+		Handler  func()
+		Y        bool
+		private  *int
+	}
+
+	weight := 3
+	root := &Service{
+		Name:     "api",
+		Replicas: 2,
+		Script:   "#!/bin/sh\necho \"started\"\n",
+		Env:      map[string]string{"DEBUG": "true", "PORT": "8080", "EMPTY": "", "NOTE": "a: b # c"},
+		Ports:    []uint16{80, 443},
+		Ratio:    math.Inf(-1),
+		Handler:  func() {},
+		private:  &weight,
+	}
+	worker := &Service{Name: "worker\tpool", Parent: root, Script: "  indented\nlines", Env: map[string]string{}}
+	root.Parent = root
+
+	cyclic := []any{"x", nil}
+	cyclic[1] = cyclic
+
+	v := map[any]any{
+		"services": []*Service{root, worker, worker},
+		"cyclic":   cyclic,
+		"pairs":    map[[2]int][]bool{{1, 2}: {true, false}},
+		"nested":   [][]int{{1, 2}, {}, nil},
+		"keep":     "trailing\n\n",
+		"newlines": "\n",
+		42:         "answer",
+	}
+
+	d := godump.Dumper{Format: godump.FormatYAML}
+	result := d.Sprint(v)
+
+	checkFromFeed(t, []byte(result), "./testdata/yaml.txt")
+
+	d = godump.Dumper{Format: godump.FormatYAML, Indentation: "\t", MaxDepth: 2, MaxItems: 2}
+	result = d.Sprint(map[string]any{
+		"list":   []int{1, 2, 3, 4, 5},
+		"map":    map[int]string{1: "a", 2: "b", 3: "c"},
+		"folded": []any{[]int{1}, struct{ A, B int }{}},
+	})
+
+	expected := `folded:
+  - [] # …
+  - {} # …2 fields
+# … 1 more …
+map:
+  1: a
+  # … 1 more …
+  3: c`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping YAML with a max depth and items: `%s`", result)
+	}
+}
+
+func TestCanDumpGoSyntax(t *testing.T) {
+	type Level int8
+
+	type Node struct {
+		Name     string
+		Level    Level
+		Next     *Node
+		Children []*Node
+		Weights  map[string]float32
+		Extra    any
+		Count    *int
+		Created  time.Time
+		OnChange func()
+		Events   chan int
+		Empty    struct{}
+		hidden   bool
+	}
+
+	count := 3
+	root := &Node{
+		Name:     "root\n\"quoted\"",
+		Level:    -2,
+		Weights:  map[string]float32{"a": 0.5, "b": float32(math.Inf(1))},
+		Extra:    []any{int8(1), 2, 2.5, float64(3), "x", Level(4), nil, []int(nil), 1 + 2i},
+		Count:    &count,
+		Created:  time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		OnChange: func() {},
+		Events:   make(chan int, 5),
+	}
+	leaf := &Node{Name: "leaf", Next: root}
+	root.Children = []*Node{leaf, leaf, {Name: "inline"}}
+
+	cyclic := map[string]any{"name": "cyclic"}
+	cyclic["self"] = cyclic
+
+	d := godump.Dumper{Format: godump.FormatGo, UseStdFormatters: true, HidePrivateFields: true}
+	result := d.Sprint(map[string]any{"root": root, "cyclic": cyclic, "ptrs": []*Node{nil}})
+
+	if _, err := parser.ParseExpr(result); err != nil {
+		t.Fatalf("invalid Go expression: %v\n%s", err, result)
+	}
+
+	checkFromFeed(t, []byte(result), "./testdata/go-syntax.txt")
+
+	d = godump.Dumper{Format: godump.FormatGo}
+	if r := d.Sprint([]any{uint8(1), "a", true, Level(2)}); r != `[]interface {}{
+   uint8(1),
+   "a",
+   true,
+   godump_test.Level(2),
+}` {
+		t.Fatalf("unexpected result when dumping Go syntax: `%s`", r)
+	}
+
+	type Fixture struct {
+		Created time.Time
+		Price   *big.Float
+		Precise big.Float
+		Large   *big.Int
+		Link    *url.URL
+		Type    reflect.Type
+		Buffer  bytes.Buffer
+		User    *url.Userinfo
+		A, B, C *int
+	}
+
+	x, y := 1, 2
+	link, _ := url.Parse("https://example.com/a?b=c")
+	fixture := Fixture{
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Price:   big.NewFloat(1.5),
+		Large:   new(big.Int).Lsh(big.NewInt(1), 80),
+		Link:    link,
+		Type:    reflect.TypeOf(0),
+		User:    url.User("me"),
+		A:       &x,
+		B:       &x,
+		C:       &y,
+	}
+	fixture.Precise.SetPrec(100).SetString("0.1")
+
+	result = d.Sprint(fixture)
+	expected := `func() godump_test.Fixture {
+   p2 := new(int)
+   *p2 = 1
+   return godump_test.Fixture{
+      Created: time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC),
+      Price: big.NewFloat(1.5),
+      Precise: *func() *big.Float { x, _, _ := big.ParseFloat("0.1", 10, 100, big.ToNearestEven); return x }(),
+      Large: func() *big.Int { x, _ := new(big.Int).SetString("1208925819614629174706176", 10); return x }(),
+      Link: func() *url.URL { u, _ := url.Parse("https://example.com/a?b=c"); return u }(),
+      Type: reflect.TypeOf((*int)(nil)).Elem(),
+      Buffer: *new(bytes.Buffer) /* unexported fields */,
+      User: new(url.Userinfo) /* unexported fields */,
+      A: p2,
+      B: p2,
+      C: func() *int { v := 2; return &v }(),
+   }
+}()`
+
+	if _, err := parser.ParseExpr(result); err != nil || result != expected {
+		t.Fatalf("unexpected result when dumping Go syntax of standard library types: `%s`", result)
+	}
+}
+
+func TestCanDumpHTML(t *testing.T) {
+	type Node struct {
+		Name     string
+		Next     *Node
+		Children []*Node
+		Labels   map[string]bool
+		Handler  func()
+		Empty    struct{}
+	}
+
+	root := &Node{Name: "<root> & \"co\"\n", Labels: map[string]bool{"a": true}}
+	child := &Node{Name: "child", Next: root}
+	root.Children = []*Node{child, child}
+
+	d := godump.Dumper{Format: godump.FormatHTML, Theme: godump.DefaultTheme}
+	d.Theme.Fields = CSSColor{1, 2, 3}
+	result := d.Sprint(root)
+
+	checkFromFeed(t, []byte(result), "./testdata/html.txt")
+
+	d = godump.Dumper{Format: godump.FormatHTML, MaxDepth: 1}
+	result = d.Sprint([]any{[]int{1, 2}, 1})
+
+	if !strings.Contains(result, `<span class="godump-body">
+   <span class="godump-types">[]int:2:2</span><span class="godump-braces"> {</span><span class="godump-elision">…</span><span class="godump-braces">}</span>,
+   <span class="godump-number">1</span>,
+</span>`) {
+		t.Fatalf("unexpected result when dumping HTML with a max depth: `%s`", result)
+	}
+}
+
+func TestCanDumpDOT(t *testing.T) {
+	type Node struct {
+		Name     string
+		Next     *Node
+		Children []*Node
+		Labels   map[string]int
+		Count    *int
+		Empty    struct{}
+	}
+
+	count := 3
+	root := &Node{Name: "<root> {a|b} \"q\"\n", Labels: map[string]int{"a": 1, "b|c": 2}, Count: &count}
+	child := &Node{Name: "child", Next: root}
+	root.Children = []*Node{child, child, nil}
+
+	d := godump.Dumper{Format: godump.FormatDOT, Theme: godump.DefaultTheme}
+	result := d.Sprint(root)
+
+	checkFromFeed(t, []byte(result), "./testdata/dot.txt")
+
+	d = godump.Dumper{Format: godump.FormatDOT, MaxDepth: 1, MaxItems: 2}
+	result = d.Sprint([]any{[]int{1, 2}, 1, 2})
+
+	expected := `digraph godump {
+   rankdir=LR;
+   node [shape=record, fontname="monospace"];
+   n1 [label="[]interface \{\}:3:3|{0|<v0> •}|… 1 more …|{2|2}"];
+   n2 [label="[]int:2:2|…"];
+   n1:v0 -> n2 [style=dashed];
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping DOT with a max depth: `%s`", result)
+	}
+}
+
+func TestCanDiff(t *testing.T) {
+	type Address struct {
+		City, Street string
+	}
+
+	type User struct {
+		Name   string
+		Age    int
+		Tags   []string
+		Meta   map[string]any
+		Home   *Address
+		Friend *User
+		Scores []int
+	}
+
+	a := &User{
+		Name:   "foo",
+		Age:    22,
+		Tags:   []string{"x", "y", "z"},
+		Meta:   map[string]any{"k": 1, "gone": true, "same": "s"},
+		Home:   &Address{"Paris", "Rue"},
+		Scores: []int{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	a.Friend = a
+
+	b := &User{
+		Name:   "bar",
+		Age:    22,
+		Tags:   []string{"w", "x", "z"},
+		Meta:   map[string]any{"k": 2, "new": false, "same": "s"},
+		Home:   &Address{"Paris", "Avenue"},
+		Scores: append(make([]int, 0, 10), 1, 2, 3, 4, 9, 6, 7, 8),
+	}
+	b.Friend = b
+
+	result := godump.Diff(a, b)
+	checkFromFeed(t, []byte(result), "./testdata/diff.txt")
+
+	if result := godump.Diff(a, a); result != "" {
+		t.Fatalf("unexpected result when diffing equal values: `%s`", result)
+	}
+
+	d := godump.Dumper{DiffContext: 1}
+	var buf bytes.Buffer
+	if err := d.Fdiff(&buf, []int{1, 2, 3, 4, 5}, []int{1, 2, 3, 9, 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ` []int:5:5 {
+    … 2 unchanged …
+    3,
+-   4,
++   9,
+    5,
+ }`
+
+	if buf.String() != expected {
+		t.Fatalf("unexpected result when diffing with context: `%s`", buf.String())
+	}
+
+	d = godump.Dumper{}
+	buf.Reset()
+	if err := d.Fdiff(&buf, 1, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "-1\n+\"1\"" {
+		t.Fatalf("unexpected result when diffing values of different types: `%s`", buf.String())
+	}
+}
+
+func TestDumperIsSafeForConcurrentUse(t *testing.T) {
+	type Node struct {
+		Name  string
+		Next  *Node
+		Items []any
+		Meta  map[string]any
+	}
+
+	values := make([]any, 8)
+	for i := range values {
+		n := &Node{Name: fmt.Sprint("node ", i), Items: []any{i, "x", nil}, Meta: map[string]any{"i": i}}
+		n.Next = n
+		values[i] = n
+	}
+
+	d := godump.Dumper{Theme: godump.DefaultTheme}
+	d.RegisterFormatter(reflect.TypeOf(0), func(w *godump.Writer, v any) {
+		w.Write(w.Theme.Number, fmt.Sprintf("int(%d)", v))
+	})
+
+	expected := make([]string, len(values))
+	for i, v := range values {
+		expected[i] = d.Sprint(v)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				k := (g + i) % len(values)
+				if result := d.Sprint(values[k]); result != expected[k] {
+					t.Errorf("unexpected result when dumping concurrently: `%s`", result)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// chunkWriter records the chunks written to it, and fails once `limit` bytes are written if it is set.
+type chunkWriter struct {
+	chunks []string
+	limit  int
+	n      int
+}
+
+var errChunkLimit = errors.New("limit reached")
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if w.limit > 0 && w.n+len(p) > w.limit {
+		n := w.limit - w.n
+		w.n += n
+		w.chunks = append(w.chunks, string(p[:n]))
+		return n, errChunkLimit
+	}
+
+	w.n += len(p)
+	w.chunks = append(w.chunks, string(p))
+	return len(p), nil
+}
+
+func TestCanStream(t *testing.T) {
+	type Entry struct {
+		Key   string
+		Value *int
+		Tags  map[string]int
+	}
+
+	entries := make([]Entry, 2000)
+	for i := range entries {
+		value := i
+		entries[i] = Entry{Key: fmt.Sprint("key ", i), Value: &value, Tags: map[string]int{"a": i, "b": -i}}
+	}
+
+	formats := []godump.Format{
+		godump.FormatText, godump.FormatJSON, godump.FormatYAML, godump.FormatGo, godump.FormatHTML, godump.FormatDOT,
+	}
+
+	for _, format := range formats {
+		d := godump.Dumper{Format: format, Theme: godump.DefaultTheme}
+
+		var w chunkWriter
+		n, err := d.Stream(&w, entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := d.Sprint(entries)
+		if result := strings.Join(w.chunks, ""); result != expected || n != int64(len(expected)) {
+			t.Fatalf("unexpected result when streaming in format %d, %d bytes written: `%s`", format, n, result)
+		}
+
+		if len(w.chunks) < 2 {
+			t.Fatalf("expected the output to be written in several chunks in format %d, got %d", format, len(w.chunks))
+		}
+	}
+
+	for _, format := range formats {
+		d := godump.Dumper{Format: format}
+		w := chunkWriter{limit: 100 << 10}
+
+		n, err := d.Stream(&w, entries)
+		if !errors.Is(err, errChunkLimit) || n != 100<<10 {
+			t.Fatalf("unexpected result when streaming to a failing writer in format %d, %d bytes written: %v", format, n, err)
+		}
+
+		if len(w.chunks) != 4 {
+			t.Fatalf("expected streaming to stop after the first write error in format %d, got %d writes", format, len(w.chunks))
+		}
+	}
+
+	type Item struct{}
+
+	var calls int
+	d := godump.Dumper{}
+	d.RegisterFormatter(reflect.TypeOf(Item{}), func(w *godump.Writer, _ any) {
+		calls++
+		w.Write(nil, "item")
+	})
+
+	w := chunkWriter{limit: 1}
+	if _, err := d.Stream(&w, make([]Item, 1<<16)); !errors.Is(err, errChunkLimit) || calls > 10000 {
+		t.Fatalf("expected the traversal to stop after the first write error, got %v after %d items", err, calls)
+	}
+}
+
+func TestCanLimitOutputSize(t *testing.T) {
+	type User struct {
+		Name    string
+		Friends []map[string]int
+	}
+
+	user := User{Name: "foo", Friends: []map[string]int{{"a": 1, "b": 2}, {"c": 3}, {"d": 4}}}
+
+	d := godump.Dumper{MaxBytes: 60}
+	result := d.Sprint(user)
+
+	expected := `godump_test.User {
+   Name: "foo",
+   Friends: []map[string]int:3:3 {
+      … truncated (budget exceeded)
+   },
+}`
+
+	if result != expected {
+		t.Fatalf("unexpected result when dumping with a byte budget: `%s`", result)
+	}
+
+	d = godump.Dumper{MaxBytes: 60, Theme: godump.DefaultTheme}
+	if result := d.Sprint(user); !strings.Contains(result, godump.DefaultTheme.Elision.Apply("… truncated (budget exceeded)")) {
+		t.Fatalf("expected the truncation marker to be styled: `%s`", result)
+	}
+
+	d = godump.Dumper{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	if err := d.FprintContext(ctx, &buf, user); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+
+	expected = `godump_test.User {
+   … truncated (context canceled)
+}`
+
+	if buf.String() != expected {
+		t.Fatalf("unexpected result when dumping with a cancelled context: `%s`", buf.String())
+	}
+
+	buf.Reset()
+	if err := d.FprintContext(context.Background(), &buf, user); err != nil || buf.String() != d.Sprint(user) {
+		t.Fatalf("unexpected result when dumping with a context: `%s`, %v", buf.String(), err)
+	}
+
+	huge := make(map[string]any, 1<<19)
+	for i := 0; i < 1<<19; i++ {
+		huge[fmt.Sprint(i)] = []any{i}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	buf.Reset()
+	start := time.Now()
+	err := d.FprintContext(ctx, &buf, huge)
+	if elapsed := time.Since(start); !errors.Is(err, context.DeadlineExceeded) || elapsed > time.Second {
+		t.Fatalf("expected to stop once the deadline is exceeded, got %v after %s", err, elapsed)
+	}
+
+	if !strings.HasPrefix(buf.String(), "map[string]interface {}:524288 {") ||
+		!strings.HasSuffix(buf.String(), "… truncated (context deadline exceeded)\n}") {
+		t.Fatalf("unexpected result when dumping a huge map with a deadline: `%s`", buf.String())
+	}
+
+	expected = `map[string]interface {}:524288 {
+   "0": []interface {}:1:1 {
+      … truncated (budget exceeded)
+   },
+}`
+
+	d = godump.Dumper{MaxBytes: 60}
+	if result := d.Sprint(huge); result != expected {
+		t.Fatalf("unexpected result when dumping a huge map with a byte budget: `%s`", result)
+	}
+
+	d = godump.Dumper{MaxBytes: 60, UnsortedMapKeys: true}
+	if result := d.Sprint(huge); !strings.HasPrefix(result, "map[string]interface {}:524288 {") ||
+		!strings.Contains(result, "… truncated (budget exceeded)") || len(result) > 200 {
+		t.Fatalf("unexpected result when dumping a huge unsorted map with a byte budget: `%s`", result)
+	}
+
+	type Step struct{}
+
+	type Job struct {
+		Steps []any
+		Done  bool
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	d = godump.Dumper{Format: godump.FormatJSON}
+	d.RegisterFormatter(reflect.TypeOf(Step{}), func(w *godump.Writer, _ any) {
+		cancel()
+		w.Write(nil, "cancel")
+	})
+
+	buf.Reset()
+	if err := d.FprintContext(ctx, &buf, Job{Steps: []any{1, Step{}, 3}, Done: true}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+
+	expected = `{
+   "$type": "godump_test.Job",
+   "Steps": [
+      1,
+      {
+         "$type": "godump_test.Step",
+         "$value": "cancel"
+      },
+      {"$truncated": "context canceled"}
+   ]
+}`
+
+	if buf.String() != expected {
+		t.Fatalf("unexpected result when cancelling the context while building JSON: `%s`", buf.String())
+	}
+
+	formats := []godump.Format{
+		godump.FormatJSON, godump.FormatYAML, godump.FormatGo, godump.FormatHTML, godump.FormatDOT,
+	}
+
+	for _, format := range formats {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		d = godump.Dumper{Format: format}
+		buf.Reset()
+		start := time.Now()
+		err := d.FprintContext(ctx, &buf, huge)
+		if elapsed := time.Since(start); !errors.Is(err, context.DeadlineExceeded) || elapsed > time.Second {
+			t.Fatalf("expected to stop format %d once the deadline is exceeded, got %v after %s", format, err, elapsed)
+		}
+		if !strings.Contains(buf.String(), "context deadline exceeded") {
+			t.Fatalf("unexpected result when dumping a huge map with a deadline in format %d: `%s`", format, buf.String())
+		}
+
+		d.MaxBytes = 60
+		if result := d.Sprint(huge); !strings.Contains(result, "budget exceeded") || len(result) > 1<<14 {
+			t.Fatalf("unexpected result when dumping a huge map with a byte budget in format %d: `%s`", format, result)
+		}
+	}
+
+	d = godump.Dumper{Format: godump.FormatJSON, MaxBytes: 60}
+	if result := d.Sprint(huge); !json.Valid([]byte(result)) {
+		t.Fatalf("expected valid JSON when dumping with a byte budget: `%s`", result)
+	}
+}
+
+type explosive int
+
+func TestCanRecoverFromPanics(t *testing.T) {
+	type Node struct {
+		A    explosive
+		B    int
+		List []explosive
+		Next *Node
+	}
+
+	node := &Node{A: 1, B: 2, List: []explosive{1, 2}}
+	node.Next = node
+
+	d := godump.Dumper{Theme: godump.DefaultTheme}
+	d.RegisterFormatter(reflect.TypeOf(explosive(0)), func(w *godump.Writer, v any) {
+		w.Write(nil, "partial output, ")
+		if v.(explosive) == 1 {
+			panic(fmt.Errorf("explosive %d", v))
+		}
+		w.Write(w.Theme.Number, "fine")
+	})
+
+	result := d.Sprint(node)
+	checkFromFeed(t, []byte(result), "./testdata/panics.txt")
+
+	d.Format = godump.FormatJSON
+	if result := d.Sprint(node); !json.Valid([]byte(result)) || !strings.Contains(result, `"A": {"$panic": "explosive 1"}`) {
+		t.Fatalf("unexpected result when dumping JSON: `%s`", result)
+	}
+
+	type Target struct {
+		Name string
+	}
+
+	type Holder struct {
+		Fuse   fuse
+		Target *Target
+	}
+
+	target := &Target{Name: "target"}
+	holder := Holder{Fuse: fuse{target}, Target: target}
+
+	d = godump.Dumper{}
+	d.RegisterFormatter(reflect.TypeOf(fuse{}), func(w *godump.Writer, v any) {
+		w.Dump(v.(fuse).target)
+		panic("fuse")
+	})
+
+	expected := `godump_test.Holder {
+   Fuse: <panic: fuse>,
+   Target: &godump_test.Target {#1
+      Name: "target",
+   },
+}`
+
+	if result := d.Sprint(holder); result != expected {
+		t.Fatalf("unexpected result when dumping a pointer after a panic: `%s`", result)
+	}
+
+	d.Format = godump.FormatJSON
+	if result := d.Sprint(holder); !strings.Contains(result, `"Fuse": {"$panic": "fuse"}`) || !strings.Contains(result, `"$id": 1`) {
+		t.Fatalf("unexpected result when dumping JSON of a pointer after a panic: `%s`", result)
+	}
+}
+
+// fuse is a value whose formatter panics once it dumped its target.
+type fuse struct {
+	target any
+}
+
+// generator generates types and values out of fuzzing input.
+type generator struct {
+	data []byte
+}
+
+func (g *generator) next() int {
+	if len(g.data) == 0 {
+		return 0
+	}
+	b := g.data[0]
+	g.data = g.data[1:]
+	return int(b)
+}
+
+func (g *generator) typ(depth int) reflect.Type {
+	kind := g.next() % 11
+	if depth > 3 {
+		kind %= 4
+	}
+
+	switch kind {
+	case 0:
+		return reflect.TypeOf(0)
+	case 1:
+		return reflect.TypeOf("")
+	case 2:
+		return reflect.TypeOf(0.0)
+	case 3:
+		return reflect.TypeOf(explosive(0))
+	case 4:
+		return reflect.SliceOf(g.typ(depth + 1))
+	case 5:
+		return reflect.MapOf(reflect.TypeOf(""), g.typ(depth+1))
+	case 6:
+		return reflect.PointerTo(g.typ(depth + 1))
+	case 7:
+		return reflect.ArrayOf(2, g.typ(depth+1))
+	case 8:
+		return reflect.TypeOf((*any)(nil)).Elem()
+	default:
+		fields := make([]reflect.StructField, 1+g.next()%3)
+		for i := range fields {
+			fields[i] = reflect.StructField{Name: fmt.Sprint("F", i), Type: g.typ(depth + 1)}
+			if g.next()%2 == 0 {
+				fields[i].Name = fmt.Sprint("f", i)
+				fields[i].PkgPath = "godump_test"
+			}
+		}
+		return reflect.StructOf(fields)
+	}
+}
+
+func (g *generator) value(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Int:
+		v.SetInt(int64(g.next()) - 128)
+	case reflect.String:
+		n := min(g.next()%8, len(g.data))
+		v.SetString(string(g.data[:n]))
+	case reflect.Float64:
+		v.SetFloat(float64(g.next()) / 7)
+	case reflect.Slice:
+		n := g.next() % 4
+		v.Set(reflect.MakeSlice(t, n, n))
+		for i := 0; i < n; i++ {
+			v.Index(i).Set(g.value(t.Elem(), depth+1))
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		for i := g.next() % 4; i > 0; i-- {
+			v.SetMapIndex(reflect.ValueOf(fmt.Sprint(g.next())), g.value(t.Elem(), depth+1))
+		}
+	case reflect.Pointer:
+		if g.next()%4 != 0 {
+			v.Set(reflect.New(t.Elem()))
+			v.Elem().Set(g.value(t.Elem(), depth+1))
+		}
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			v.Index(i).Set(g.value(t.Elem(), depth+1))
+		}
+	case reflect.Interface:
+		if g.next()%2 == 0 && depth < 4 {
+			v.Set(g.value(g.typ(depth+1), depth+1))
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := v.Field(i)
+			if !f.CanSet() {
+				f = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+			}
+			f.Set(g.value(t.Field(i).Type, depth+1))
+		}
+	}
+
+	if t.Kind() == reflect.Int && t != reflect.TypeOf(0) {
+		v.SetInt(int64(g.next() % 3))
+	}
+	return v
+}
+
+func FuzzDump(f *testing.F) {
+	f.Add([]byte{9, 2, 3, 1, 0, 4, 6, 3, 2, 1})
+	f.Add([]byte{4, 5, 9, 1, 3, 3, 0, 2, 1, 1, 3, 8, 0, 7, 7})
+	f.Add([]byte{6, 9, 2, 8, 3, 1, 0, 4, 3, 2, 1, 1, 5, 3, 3, 7, 9, 2, 6})
+	f.Add([]byte("godump fuzzing seed with some more bytes"))
+
+	formats := []godump.Format{
+		godump.FormatText, godump.FormatJSON, godump.FormatYAML, godump.FormatGo, godump.FormatHTML, godump.FormatDOT,
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		g := generator{data: data}
+		typ := g.typ(0)
+		v := g.value(typ, 0).Interface()
+
+		for _, format := range formats {
+			d := godump.Dumper{Format: format, Theme: godump.DefaultTheme, UseStringer: true, MaxItems: 3}
+			d.RegisterFormatter(reflect.TypeOf(explosive(0)), func(w *godump.Writer, v any) {
+				if v.(explosive) == 1 {
+					panic("explosive")
+				}
+				w.Write(w.Theme.Number, fmt.Sprint(v))
+			})
+
+			result := d.Sprint(v)
+			if format == godump.FormatJSON && !json.Valid([]byte(result)) {
+				t.Fatalf("invalid JSON: `%s`", result)
+			}
+		}
+
+		_ = godump.Diff(v, g.value(typ, 0).Interface())
+	})
+}
+
+func TestCanRedactSensitiveValues(t *testing.T) {
+	type Credentials struct {
+		Username string
+		Password string
+		APIToken *string
+		Key      []byte `dump:"redact"`
+		Headers  map[string]any
+		Options  map[any]string
+	}
+
+	token := "token-value"
+	creds := Credentials{
+		Username: "admin",
+		Password: "password-value",
+		APIToken: &token,
+		Key:      []byte("key-value"),
+		Headers: map[string]any{
+			"Accept":        "text/plain",
+			"Authorization": "Bearer token-value",
+			"X-Secret":      []string{"secret-value"},
+			"Session":       map[string]string{"csrf_token": "csrf-value"},
+		},
+		Options: map[any]string{1: "one", "client_secret": "secret-value"},
+	}
+
+	d := godump.Dumper{
+		Theme:  godump.DefaultTheme,
+		Redact: []*regexp.Regexp{godump.SensitiveNames, regexp.MustCompile(`^Authorization$`)},
+	}
+
+	result := d.Sprint(creds)
+	checkFromFeed(t, []byte(result), "./testdata/redaction.txt")
+
+	formats := []godump.Format{
+		godump.FormatJSON, godump.FormatYAML, godump.FormatGo, godump.FormatHTML, godump.FormatDOT,
+	}
+
+	for _, format := range formats {
+		d.Format = format
+		result := d.Sprint(creds)
+		if strings.Contains(result, "-value") || !strings.Contains(result, "[REDACTED]") || !strings.Contains(result, "admin") {
+			t.Fatalf("unexpected result when dumping format %d: `%s`", format, result)
+		}
+	}
+
+	d = godump.Dumper{}
+	if result := d.Sprint(creds); strings.Contains(result, "key-value") || !strings.Contains(result, "password-value") {
+		t.Fatalf("unexpected result when dumping without patterns: `%s`", result)
+	}
+
+	d = godump.Dumper{Redact: []*regexp.Regexp{godump.SensitiveNames}}
+	var buf strings.Builder
+	_ = d.Fdiff(&buf, creds, creds)
+	if buf.String() != "" {
+		t.Fatalf("unexpected differences between the same values: `%s`", buf.String())
+	}
+
+	changed := creds
+	changed.Password = "other-value"
+	changed.Headers = map[string]any{"Session": map[string]string{"csrf_token": "other-value"}}
+	for k, v := range creds.Headers {
+		if k != "Session" {
+			changed.Headers[k] = v
+		}
+	}
+
+	expected := ` godump_test.Credentials {
+    … 1 unchanged …
+-   Password: string [REDACTED],
++   Password: string [REDACTED] (changed),
+    … 2 unchanged …
+    Headers: map[string]interface {}:4 {
+       … 2 unchanged …
+       "Session": map[string]string:1 {
+-         "csrf_token": string [REDACTED],
++         "csrf_token": string [REDACTED] (changed),
+       },
+       … 1 unchanged …
+    },
+    … 1 unchanged …
+ }`
+
+	_ = d.Fdiff(&buf, creds, changed)
+	if buf.String() != expected {
+		t.Fatalf("unexpected differences between redacted values: `%s`", buf.String())
+	}
+}
+
 func TestCanCustomizeIndentation(t *testing.T) {
 	type User struct {
 		Name       string
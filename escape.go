@@ -0,0 +1,127 @@
+package godump
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Escaping defines how special characters within strings are printed.
+type Escaping int
+
+const (
+	// EscapeQuoted escapes strings the way [strconv.Quote] does, eg., "\n" for a new line. This is the default.
+	EscapeQuoted Escaping = iota
+
+	// EscapeVisible replaces control characters with their Unicode control pictures, eg., "␊" for a new line.
+	// Quotes and backslashes are kept as they are.
+	EscapeVisible
+
+	// EscapeNone prints strings as they are.
+	EscapeNone
+)
+
+// quote returns the styled string `s` surrounded by quotes.
+//
+// It is cut to [Dumper.MaxStringLen] bytes, and printed as a block of lines if [Dumper.MultilineStrings] is set.
+func (d *renderer) quote(s string) string {
+	var cut int
+	if d.MaxStringLen > 0 && len(s) > int(d.MaxStringLen) {
+		n := int(d.MaxStringLen)
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		cut, s = len(s)-n, s[:n]
+	}
+
+	var str string
+	if d.MultilineStrings && strings.Contains(s, "\n") {
+		str = d.block(s)
+	} else {
+		str = __(d.Theme.Quotes, `"`) + d.escape(s, false) + __(d.Theme.Quotes, `"`)
+	}
+
+	switch {
+	case cut == 1:
+		str += " " + __(d.Theme.Elision, "… 1 more byte")
+	case cut > 1:
+		str += " " + __(d.Theme.Elision, fmt.Sprintf("… %s more bytes", thousands(cut)))
+	}
+	return str
+}
+
+// block returns the styled string `s` as a block of lines surrounded by triple quotes.
+// Each line is prefixed with a gutter, and aligned with the current indentation.
+func (d *renderer) block(s string) string {
+	indent := strings.Repeat(d.Indentation, int(d.depth))
+
+	var b strings.Builder
+	b.WriteString(__(d.Theme.Quotes, `"""`))
+	for _, line := range strings.Split(s, "\n") {
+		b.WriteString("\n" + indent + __(d.Theme.Quotes, "│"))
+		if line != "" {
+			b.WriteString(" " + d.escape(line, true))
+		}
+	}
+	b.WriteString("\n" + indent + __(d.Theme.Quotes, `"""`))
+
+	return b.String()
+}
+
+// escape returns the styled content of the string `s`, escaped according to [Dumper.Escaping].
+//
+// Ordinary text is styled using [Theme.String], while escape sequences are styled using [Theme.Escape].
+// Invalid UTF-8 bytes are printed as '\xNN' unless escaping is disabled. Quotes are not escaped within blocks.
+func (d *renderer) escape(s string, inBlock bool) string {
+	if d.Escaping == EscapeNone {
+		return __(d.Theme.String, s)
+	}
+
+	var out, text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			out.WriteString(__(d.Theme.String, text.String()))
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		if seq := d.escapeRune(r, size, s[i], inBlock); seq != "" {
+			flush()
+			out.WriteString(__(d.Theme.Escape, seq))
+		} else {
+			text.WriteString(s[i : i+size])
+		}
+
+		i += size
+	}
+	flush()
+
+	return out.String()
+}
+
+// escapeRune returns the escape sequence of the rune `r` encoded in `size` bytes starting with byte `b`,
+// or an empty string if it doesn't need to be escaped.
+func (d *renderer) escapeRune(r rune, size int, b byte, inBlock bool) string {
+	switch {
+	case r == utf8.RuneError && size == 1:
+		return fmt.Sprintf(`\x%02x`, b)
+	case d.Escaping == EscapeVisible && r < ' ':
+		return string(rune(0x2400) + r)
+	case d.Escaping == EscapeVisible && r == 0x7f:
+		return "␡"
+	case r == '"' || r == '\\':
+		if d.Escaping == EscapeVisible || (inBlock && r == '"') {
+			return ""
+		}
+		return `\` + string(r)
+	case strconv.IsPrint(r):
+		return ""
+	default:
+		q := strconv.QuoteRune(r)
+		return q[1 : len(q)-1]
+	}
+}
@@ -0,0 +1,77 @@
+package godump
+
+// Format defines the output format of the [Dumper].
+type Format int
+
+const (
+	// FormatText prints values in the coloured and structured text format. This is the default.
+	FormatText Format = iota
+
+	// FormatJSON prints values as indented JSON.
+	//
+	// Structs and maps become objects holding their type in a "$type" key, and slices and arrays become arrays.
+	// Pointers, and maps and slices that contain themselves, are given an "$id", and repeated ones are printed
+	// as {"$ref": id}. Map keys that are not strings, numbers or booleans are printed as a list of key-value pairs
+	// under "$entries", and keys starting with '$' are escaped with another one. Functions, channels and unsafe
+	// pointers become descriptive strings, and so do numbers JSON cannot hold. Values folded or elided because
+	// of [Dumper.MaxDepth] and [Dumper.MaxItems] are marked with "$folded" and "$elided", and the ones left out
+	// because of [Dumper.MaxBytes] or [Dumper.FprintContext] with "$truncated".
+	FormatJSON
+
+	// FormatYAML prints values as a YAML document.
+	//
+	// Structs and maps become mappings, and slices and arrays become sequences. Strings spanning several lines
+	// are printed as literal block scalars, and strings that would be read as something else are quoted.
+	// Pointers, and maps and slices that contain themselves, are given an anchor, eg., &id001, and repeated ones
+	// are printed as an alias, eg., *id001. Folded, elided and truncated values are marked with comments.
+	// [Dumper.Indentation] is replaced by two spaces unless it is made of two spaces or more.
+	FormatYAML
+
+	// FormatGo prints values as Go expressions that can be compiled, eg., to be pasted into tests as fixtures.
+	//
+	// Structs, maps, slices and arrays become composite literals, and pointers to them take their address.
+	// Values are converted to their type where it cannot be inferred, and types are qualified with the name
+	// of their package. Pointers, maps and slices referred to several times are declared as helper variables
+	// within a function literal returning the value. Stringers and registered formatters are ignored, while
+	// some standard library types are printed using their constructors, eg., time.Date, whether
+	// [Dumper.UseStdFormatters] is set or not. Other standard library structs having unexported fields, functions
+	// and unsafe pointers cannot be reconstructed, they become a zero value followed by a comment.
+	// Folded, elided and truncated values are marked with comments as well.
+	FormatGo
+
+	// FormatHTML prints values as a standalone HTML document, laid out like the text format.
+	//
+	// Structs, maps and slices can be collapsed by clicking their type, and references '@x' are links
+	// to the values they refer to. The colours of the [RGB] styles of the theme are set as CSS variables,
+	// eg., --godump-string, the other styles are ignored.
+	FormatHTML
+
+	// FormatDOT prints the graph of values in the Graphviz DOT language, eg., to be rendered with `dot -Tsvg`.
+	//
+	// Structs, maps, slices and arrays become record vertices holding a row per field, entry or item.
+	// Scalars are written in place, the way the text format prints them, while pointers are edges to the vertices
+	// of the values they point to, and nested structs, maps and slices are dashed edges. Values referred to
+	// several times are a single vertex with converging edges, and references to the values containing them
+	// are back edges.
+	FormatDOT
+)
+
+// render writes `v` to the buffer in the format of the Dumper.
+func (d *renderer) render(v any) {
+	val := d.init(v)
+
+	switch d.Format {
+	case FormatJSON:
+		d.writeJSON(d.build(val))
+	case FormatYAML:
+		d.writeYAMLDocument(d.build(val))
+	case FormatGo:
+		d.writeGo(d.build(val))
+	case FormatHTML:
+		d.writeHTMLDocument(d.build(val))
+	case FormatDOT:
+		d.writeDOT(d.build(val))
+	default:
+		d.dump(val)
+	}
+}
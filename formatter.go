@@ -0,0 +1,123 @@
+package godump
+
+import (
+	"reflect"
+)
+
+// Formatter defines how values of a given type are printed, see [Dumper.RegisterFormatter].
+//
+// It receives the value to be printed, and a [Writer] to print it with.
+type Formatter func(w *Writer, v any)
+
+type formatter struct {
+	typ reflect.Type
+	fn  Formatter
+
+	// text returns the plain text of values, it is only known for standard library types.
+	text func(v any) string
+
+	// syntax returns the Go expression of values, it is only known for some standard library types.
+	syntax func(v any) string
+}
+
+// RegisterFormatter registers the formatter `fn` for values of type `t`, replacing the default output for them.
+//
+// If `t` is an interface type, `fn` is used for all values implementing it, unless a formatter is registered
+// for their exact type. When several interfaces match a value, the one registered first wins.
+// Nil values are printed as usual. Values held by unexported fields, including map values and the values of
+// interfaces, are formatted as well.
+func (d *Dumper) RegisterFormatter(t reflect.Type, fn Formatter) {
+	for i, f := range d.formatters {
+		if f.typ == t {
+			d.formatters[i].fn = fn
+			return
+		}
+	}
+	d.formatters = append(d.formatters, formatter{typ: t, fn: fn})
+}
+
+// formatter returns the formatter registered for the type of `v`, or nil if none.
+// Registered formatters take precedence over the ones of standard library types.
+func (d *renderer) formatter(v reflect.Value) *formatter {
+	if v.Kind() == reflect.Interface || isNil(v) {
+		return nil
+	}
+
+	if fn := lookupFormatter(d.formatters, v.Type()); fn != nil {
+		return fn
+	}
+
+	if d.UseStdFormatters || d.Format == FormatGo {
+		return lookupFormatter(stdFormatters, v.Type())
+	}
+	return nil
+}
+
+// lookupFormatter returns the formatter of the type `t` among `formatters`, or nil if none.
+func lookupFormatter(formatters []formatter, t reflect.Type) *formatter {
+	for i, f := range formatters {
+		if f.typ == t {
+			return &formatters[i]
+		}
+	}
+
+	for i, f := range formatters {
+		if f.typ.Kind() == reflect.Interface && t.Implements(f.typ) {
+			return &formatters[i]
+		}
+	}
+
+	return nil
+}
+
+// dumpFormatter prints `v` using the formatter `f`, it reports false if `v` cannot be passed to it.
+func (d *renderer) dumpFormatter(v reflect.Value, f *formatter) bool {
+	v, ok := exported(v)
+	if !ok {
+		return false
+	}
+
+	d.ptrTag = 0
+	w := Writer{Theme: d.Theme, d: d, base: d.depth}
+	f.fn(&w, v.Interface())
+	d.depth = w.base
+
+	return true
+}
+
+// Writer is used by formatters to print values, it honours the theme and indentation of the [Dumper].
+type Writer struct {
+	// Theme is the theme of the Dumper.
+	Theme Theme
+
+	d    *renderer
+	base uint
+}
+
+// Write writes `s` styled using `style`, which may be nil.
+func (w *Writer) Write(style Style, s string) {
+	w.d.buf.WriteString(__(style, s))
+}
+
+// Newline starts a new line, indented at the current depth.
+func (w *Writer) Newline() {
+	w.d.buf.WriteString("\n")
+	w.d.indent()
+}
+
+// Indent increases the depth of the lines that follow.
+func (w *Writer) Indent() {
+	w.d.depth++
+}
+
+// Dedent decreases the depth of the lines that follow.
+func (w *Writer) Dedent() {
+	if w.d.depth > w.base {
+		w.d.depth--
+	}
+}
+
+// Dump prints `v` as the [Dumper] would, at the current depth.
+func (w *Writer) Dump(v any) {
+	w.d.dump(reflect.ValueOf(v), true)
+}
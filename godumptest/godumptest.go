@@ -0,0 +1,59 @@
+// Package godumptest provides test helpers printing values with [godump.Dumper], and reporting the
+// differences between them when they are not equal.
+package godumptest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yassinebenaid/godump"
+)
+
+// Dumper is the Dumper used by the helpers, it is copied on each call. Its theme is ignored while running
+// tests, as reported by [testing.Testing], so that test logs are free of ANSI escape sequences.
+var Dumper = godump.Dumper{Theme: godump.DefaultTheme}
+
+// Equal reports whether `got` and `want` are equal, as compared by [godump.Dumper.Fdiff] with the default options,
+// so that options such as [godump.Dumper.MaxDepth] or [godump.Dumper.UseStringer] do not hide any difference.
+// If they are not, the test is marked as failed and their differences are logged using [Dumper], followed by
+// both values. All of the differences are logged if the options of [Dumper] hide them.
+func Equal(t testing.TB, got, want any) bool {
+	t.Helper()
+
+	diff := fdiff(godump.Dumper{}, want, got)
+	if diff == "" {
+		return true
+	}
+
+	d := dumper()
+	if s := fdiff(d, want, got); s != "" {
+		diff = s
+	}
+
+	t.Errorf("values are not equal (-want +got):\n%s\n\ngot:\n%s\n\nwant:\n%s", diff, d.Sprint(got), d.Sprint(want))
+	return false
+}
+
+// Log logs `v` to the test.
+func Log(t testing.TB, v any) {
+	t.Helper()
+
+	d := dumper()
+	t.Log("\n" + d.Sprint(v))
+}
+
+// fdiff returns the differences between `a` and `b` as printed by `d`.
+func fdiff(d godump.Dumper, a, b any) string {
+	var diff strings.Builder
+	_ = d.Fdiff(&diff, a, b)
+	return diff.String()
+}
+
+// dumper returns a copy of [Dumper], without theme while running tests.
+func dumper() godump.Dumper {
+	d := Dumper
+	if testing.Testing() {
+		d.Theme = godump.Theme{}
+	}
+	return d
+}
@@ -0,0 +1,194 @@
+package godumptest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yassinebenaid/godump"
+	"github.com/yassinebenaid/godump/godumptest"
+)
+
+// recorder is a [testing.TB] recording the messages logged to it.
+type recorder struct {
+	testing.TB
+
+	failed bool
+	logs   []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Log(args ...any) {
+	r.logs = append(r.logs, fmt.Sprint(args...))
+}
+
+func TestEqual(t *testing.T) {
+	type User struct {
+		Name string
+		Age  int
+	}
+
+	r := &recorder{TB: t}
+	if !godumptest.Equal(r, User{"foo", 22}, User{"foo", 22}) || r.failed || len(r.logs) != 0 {
+		t.Fatalf("unexpected failure when comparing equal values: %q", r.logs)
+	}
+
+	if godumptest.Equal(r, User{"foo", 23}, User{"foo", 22}) || !r.failed {
+		t.Fatal("expected a failure when comparing different values")
+	}
+
+	expected := `values are not equal (-want +got):
+ godumptest_test.User {
+    … 1 unchanged …
+-   Age: 22,
++   Age: 23,
+ }
+
+got:
+godumptest_test.User {
+   Name: "foo",
+   Age: 23,
+}
+
+want:
+godumptest_test.User {
+   Name: "foo",
+   Age: 22,
+}`
+
+	if len(r.logs) != 1 || r.logs[0] != expected {
+		t.Fatalf("unexpected failure message: %q", r.logs)
+	}
+
+	type Tree struct {
+		Label    label
+		Children []Tree
+	}
+
+	defer func(d godump.Dumper) { godumptest.Dumper = d }(godumptest.Dumper)
+	godumptest.Dumper.MaxDepth, godumptest.Dumper.MaxItems, godumptest.Dumper.UseStringer = 1, 1, true
+
+	tree := Tree{Label: label{"root", 1}, Children: []Tree{{Label: label{"a", 2}}, {Label: label{"b", 3}}}}
+	values := []Tree{
+		{Label: label{"root", 1}, Children: []Tree{{Label: label{"a", 2}}, {Label: label{"c", 3}}}},
+		{Label: label{"root", 1}, Children: []Tree{{Label: label{"a", 2}, Children: []Tree{}}, {Label: label{"b", 3}}}},
+		{Label: label{"root", 4}, Children: []Tree{{Label: label{"a", 2}}, {Label: label{"b", 3}}}},
+	}
+
+	for _, v := range values {
+		r = &recorder{TB: t}
+		if godumptest.Equal(r, v, tree) || !r.failed || len(r.logs) != 1 || !strings.Contains(r.logs[0], "+") {
+			t.Fatalf("expected a failure when comparing values differing in what the options hide: %q", r.logs)
+		}
+	}
+
+	type Config struct {
+		Key string `dump:"redact"`
+	}
+
+	r = &recorder{TB: t}
+	if godumptest.Equal(r, Config{"secret-b"}, Config{"secret-a"}) || !r.failed || len(r.logs) != 1 ||
+		!strings.Contains(r.logs[0], "+   Key: string [REDACTED] (changed),") || strings.Contains(r.logs[0], "secret-") {
+		t.Fatalf("expected a failure when comparing values differing in redacted fields: %q", r.logs)
+	}
+}
+
+// label is a stringer printing only part of its fields.
+type label struct {
+	Text string
+	ID   int
+}
+
+func (l label) String() string {
+	return l.Text
+}
+
+func TestLog(t *testing.T) {
+	r := &recorder{TB: t}
+	godumptest.Log(r, map[string]int{"a": 1})
+
+	if len(r.logs) != 1 || r.logs[0] != "\nmap[string]int:1 {\n   \"a\": 1,\n}" {
+		t.Fatalf("unexpected log: %q", r.logs)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	type User struct {
+		Name string
+		Tags map[string]int
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd) //nolint:errcheck
+
+	user := User{Name: "foo", Tags: map[string]int{"b": 2, "a": 1, "c": 3}}
+
+	r := &recorder{TB: t}
+	if godumptest.Snapshot(r, "user", user) || !r.failed {
+		t.Fatal("expected a failure when the golden file does not exist")
+	}
+
+	t.Setenv(godumptest.UpdateEnv, "1")
+	r = &recorder{TB: t}
+	if !godumptest.Snapshot(r, "user", user) || r.failed {
+		t.Fatalf("unexpected failure when updating the golden file: %q", r.logs)
+	}
+
+	path := filepath.Join("testdata", "TestSnapshot", "user.golden")
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `godumptest_test.User {
+   Name: "foo",
+   Tags: map[string]int:3 {
+      "a": 1,
+      "b": 2,
+      "c": 3,
+   },
+}
+`
+	if string(golden) != expected {
+		t.Fatalf("unexpected golden file: %q", golden)
+	}
+
+	t.Setenv(godumptest.UpdateEnv, "")
+	r = &recorder{TB: t}
+	if !godumptest.Snapshot(r, "user", user) || r.failed {
+		t.Fatalf("unexpected failure when matching the golden file: %q", r.logs)
+	}
+
+	user.Tags["b"] = 20
+	if godumptest.Snapshot(r, "user", user) || !r.failed {
+		t.Fatal("expected a failure when the output changes")
+	}
+
+	expected = "output does not match golden file " + path + ` (-want +got):
+  … 2 unchanged lines …
+     Tags: map[string]int:3 {
+        "a": 1,
+-       "b": 2,
++       "b": 20,
+        "c": 3,
+     },
+  … 2 unchanged lines …`
+
+	if len(r.logs) != 1 || r.logs[0] != expected {
+		t.Fatalf("unexpected failure message: %q", r.logs)
+	}
+}
@@ -0,0 +1,133 @@
+package godumptest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yassinebenaid/godump"
+	"github.com/yassinebenaid/godump/internal/lcs"
+)
+
+// UpdateEnv is the environment variable that, when set to a non-empty value, makes [Snapshot] rewrite
+// the golden files instead of comparing values against them.
+const UpdateEnv = "GODUMP_UPDATE"
+
+// Snapshot compares `v` to the golden file testdata/<TestName>/<name>.golden, and reports whether it matches.
+// If it does not, the test is marked as failed and the differences between the lines are logged.
+//
+// Values are printed without colour and with sorted map keys, so that the output is the same across runs.
+// Golden files are written instead when the [UpdateEnv] environment variable is set, or when the -update flag
+// is set. The flag is not defined by this package, since test packages often define it themselves, eg.:
+//
+//	var _ = flag.Bool("update", false, "update golden files")
+func Snapshot(t testing.TB, name string, v any) bool {
+	t.Helper()
+
+	d := dumper()
+	d.Theme = godump.Theme{}
+	d.UnsortedMapKeys = false
+	got := d.Sprintln(v)
+
+	path := filepath.Join("testdata", t.Name(), name+".golden")
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("golden file %s does not exist, set %s=1 to create it", path, UpdateEnv)
+		return false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(want) == got {
+		return true
+	}
+
+	t.Errorf("output does not match golden file %s (-want +got):\n%s", path, lineDiff(string(want), got))
+	return false
+}
+
+// updating reports whether golden files are to be rewritten.
+func updating() bool {
+	if os.Getenv(UpdateEnv) != "" {
+		return true
+	}
+
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// lineContext is the number of unchanged lines printed around changed ones by [lineDiff].
+const lineContext = 2
+
+// maxLineDiff is the largest number of line comparisons made by [lineDiff], above which the lines that differ
+// are printed in full.
+const maxLineDiff = 1 << 20
+
+// lineDiff returns the lines of `want` and `got` that differ, marked with '-' and '+', and the lines around them.
+func lineDiff(want, got string) string {
+	x, y := strings.Split(want, "\n"), strings.Split(got, "\n")
+
+	// ops are the lines of the differences, each one prefixed with its marker.
+	var ops []string
+	i, j := 0, 0
+	for _, op := range lcs.Align(len(x), len(y), maxLineDiff, func(i, j int) bool { return x[i] == y[j] }) {
+		switch op {
+		case lcs.Keep:
+			ops = append(ops, " "+x[i])
+			i++
+			j++
+		case lcs.Remove:
+			ops = append(ops, "-"+x[i])
+			i++
+		default:
+			ops = append(ops, "+"+y[j])
+			j++
+		}
+	}
+
+	var b strings.Builder
+	skipped := 0
+	for i, op := range ops {
+		if op[0] == ' ' && !nearChange(ops, i) {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			fmt.Fprintf(&b, "  … %d unchanged lines …\n", skipped)
+			skipped = 0
+		}
+		b.WriteString(op[:1] + " " + op[1:] + "\n")
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&b, "  … %d unchanged lines …\n", skipped)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// nearChange reports whether a changed line is within [lineContext] lines of the line `i` of `ops`.
+func nearChange(ops []string, i int) bool {
+	for j := max(0, i-lineContext); j <= min(len(ops)-1, i+lineContext); j++ {
+		if ops[j][0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,461 @@
+package godump
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	boolType       = reflect.TypeOf(false)
+	intType        = reflect.TypeOf(0)
+	float64Type    = reflect.TypeOf(0.0)
+	complex128Type = reflect.TypeOf(0i)
+	stringType     = reflect.TypeOf("")
+)
+
+// goSyntax writes nodes as Go syntax, see [FormatGo].
+type goSyntax struct {
+	d *renderer
+
+	// refs are the ids references refer to, the values having them are hoisted into helper variables.
+	refs map[uint]bool
+
+	// helpers are the helper variables declared so far, by id.
+	helpers map[uint]helper
+
+	// decls and stmts are the statements declaring helper variables, and the ones filling them.
+	decls, stmts []string
+}
+
+// helper is a variable holding a value that is referred to several times.
+type helper struct {
+	name string
+
+	// collection reports whether the variable holds a map or a slice, rather than a pointer.
+	collection bool
+}
+
+// writeGo writes the node `n` as a Go expression. Values referred to several times are declared as helper
+// variables within a function literal returning the value, so that the expression is still self-contained.
+func (d *renderer) writeGo(n *node) {
+	g := goSyntax{d: d, refs: make(map[uint]bool), helpers: make(map[uint]helper)}
+	g.collect(n)
+
+	if len(g.refs) == 0 {
+		g.write(n, nil, false)
+		return
+	}
+
+	d.depth++
+	expr := g.capture(n, n.typ, false)
+	d.depth--
+
+	d.buf.WriteString("func() " + n.typ.String() + " {")
+	for _, stmt := range append(g.decls, g.stmts...) {
+		d.buf.WriteString("\n" + d.Indentation + stmt)
+	}
+	d.buf.WriteString("\n" + d.Indentation + "return " + expr + "\n}()")
+}
+
+// collect records the ids references within `n` refer to.
+func (g *goSyntax) collect(n *node) {
+	switch n.kind {
+	case nodeRef:
+		g.refs[n.id] = true
+	case nodePointer:
+		g.collect(n.elem)
+	case nodeStruct:
+		for _, f := range n.fields {
+			g.collect(f.value)
+		}
+	case nodeMap:
+		for _, e := range n.entries {
+			g.collect(e.key)
+			g.collect(e.value)
+		}
+	case nodeList:
+		for _, item := range n.items {
+			g.collect(item)
+		}
+	}
+}
+
+// capture returns the Go expression of `n` instead of writing it.
+func (g *goSyntax) capture(n *node, ctx reflect.Type, implicit bool) string {
+	return g.d.capture(func() { g.write(n, ctx, implicit) })
+}
+
+// write writes the Go expression of `n`, to be used where a value of type `ctx` is expected, which is nil
+// at the top level. The type of composite literals is omitted when `implicit` is set and it is `ctx`.
+func (g *goSyntax) write(n *node, ctx reflect.Type, implicit bool) {
+	d := g.d
+	d.flush()
+
+	switch n.kind {
+	case nodeRef:
+		d.buf.WriteString(g.ref(n))
+	case nodePointer:
+		if n.id != 0 && g.refs[n.id] {
+			d.buf.WriteString(g.hoist(n))
+			return
+		}
+
+		switch n.elem.kind {
+		case nodeStruct, nodeMap, nodeList:
+			if n.elem.kind == nodeStruct && sealed(n.elem.typ) {
+				d.buf.WriteString(goSealed("new", n.elem.typ))
+				return
+			}
+			if implicit && ctx == n.typ {
+				g.write(n.elem, n.typ.Elem(), true)
+				return
+			}
+			d.buf.WriteString("&")
+			g.write(n.elem, n.typ.Elem(), false)
+		default:
+			d.buf.WriteString(fmt.Sprintf("func() %s { v := ", n.typ))
+			g.write(n.elem, nil, false)
+			d.buf.WriteString("; return &v }()")
+		}
+	case nodeStruct:
+		g.writeStruct(n, implicit && ctx == n.typ)
+	case nodeMap:
+		if n.id != 0 && g.refs[n.id] {
+			d.buf.WriteString(g.define(n))
+			return
+		}
+		g.writeMap(n, implicit && ctx == n.typ)
+	case nodeList:
+		if n.id != 0 && g.refs[n.id] {
+			d.buf.WriteString(g.define(n))
+			return
+		}
+		g.writeList(n, implicit && ctx == n.typ)
+	default:
+		d.buf.WriteString(goScalar(n, ctx))
+	}
+}
+
+func (g *goSyntax) writeStruct(n *node, implicit bool) {
+	d := g.d
+
+	if sealed(n.typ) {
+		d.buf.WriteString(goSealed("*new", n.typ))
+		return
+	}
+
+	g.open(n, implicit)
+	if n.folded {
+		d.buf.WriteString("/* … */}")
+		return
+	}
+
+	var hasFields bool
+
+	d.depth++
+	for _, f := range n.fields {
+		if f.name == "_" {
+			continue
+		}
+
+		hasFields = true
+		sf, _ := n.typ.FieldByName(f.name)
+
+		d.buf.WriteString("\n")
+		d.indent()
+		d.buf.WriteString(f.name + ": ")
+		g.write(f.value, sf.Type, false)
+		d.buf.WriteString(",")
+	}
+	truncated := g.writeTruncation(n)
+	d.depth--
+
+	g.close(hasFields || truncated)
+}
+
+func (g *goSyntax) writeMap(n *node, implicit bool) {
+	d := g.d
+
+	g.open(n, implicit)
+	if n.folded {
+		d.buf.WriteString("/* … */}")
+		return
+	}
+
+	d.depth++
+	for i := 0; i <= len(n.entries); i++ {
+		if i == n.head && n.elided > 0 {
+			g.writeElision(n.elided)
+		}
+
+		if i < len(n.entries) {
+			d.buf.WriteString("\n")
+			d.indent()
+			g.write(n.entries[i].key, n.typ.Key(), true)
+			d.buf.WriteString(": ")
+			g.write(n.entries[i].value, n.typ.Elem(), true)
+			d.buf.WriteString(",")
+		}
+	}
+	truncated := g.writeTruncation(n)
+	d.depth--
+
+	g.close(len(n.entries) > 0 || truncated)
+}
+
+func (g *goSyntax) writeList(n *node, implicit bool) {
+	d := g.d
+
+	g.open(n, implicit)
+	if n.folded {
+		d.buf.WriteString("/* … */}")
+		return
+	}
+
+	d.depth++
+	for i := 0; i <= len(n.items); i++ {
+		if i == n.head && n.elided > 0 {
+			g.writeElision(n.elided)
+		}
+
+		if i < len(n.items) {
+			d.buf.WriteString("\n")
+			d.indent()
+			g.write(n.items[i], n.typ.Elem(), true)
+			d.buf.WriteString(",")
+		}
+	}
+	truncated := g.writeTruncation(n)
+	d.depth--
+
+	g.close(len(n.items) > 0 || truncated)
+}
+
+// open writes the type of the composite literal of `n`, unless it is `implicit`, and its opening brace.
+func (g *goSyntax) open(n *node, implicit bool) {
+	if !implicit {
+		g.d.buf.WriteString(n.typ.String())
+	}
+	g.d.buf.WriteString("{")
+}
+
+// close writes the closing brace of a composite literal, on its own line if it has elements.
+func (g *goSyntax) close(hasElements bool) {
+	if hasElements {
+		g.d.buf.WriteString("\n")
+		g.d.indent()
+	}
+	g.d.buf.WriteString("}")
+}
+
+// writeElision writes the comment marking `n` elided items on its own line.
+func (g *goSyntax) writeElision(n int) {
+	g.d.buf.WriteString("\n")
+	g.d.indent()
+	g.d.buf.WriteString(fmt.Sprintf("/* … %s more … */", thousands(n)))
+}
+
+// writeTruncation writes the comment marking the truncation of the struct, map or list node `n` on its own line,
+// it reports false if there is none.
+func (g *goSyntax) writeTruncation(n *node) bool {
+	if n.truncated == "" {
+		return false
+	}
+
+	g.d.buf.WriteString("\n")
+	g.d.indent()
+	g.d.buf.WriteString("/* … truncated (" + n.truncated + ") */")
+	return true
+}
+
+// hoist declares the helper variable of the pointer node `n`, and returns the expression of the pointer.
+// Pointers to maps and slices that contain themselves are the address of the variable holding them.
+func (g *goSyntax) hoist(n *node) string {
+	if e := n.elem; e.id == n.id && (e.kind == nodeMap || e.kind == nodeList) {
+		return "&" + g.define(e)
+	}
+
+	name := fmt.Sprintf("p%d", n.id)
+	g.helpers[n.id] = helper{name: name}
+	g.decls = append(g.decls, fmt.Sprintf("%s := new(%s)", name, n.typ.Elem()))
+	g.addStmt(func() string {
+		return fmt.Sprintf("*%s = %s", name, g.capture(n.elem, n.typ.Elem(), false))
+	})
+
+	return name
+}
+
+// define declares the helper variable of the map or list node `n` and fills it, and returns its name.
+func (g *goSyntax) define(n *node) string {
+	if n.kind == nodeMap {
+		name := fmt.Sprintf("m%d", n.id)
+		g.helpers[n.id] = helper{name: name, collection: true}
+		g.decls = append(g.decls, fmt.Sprintf("%s := make(%s, %d)", name, n.typ, n.len))
+
+		for i, e := range n.entries {
+			if i == n.head && n.elided > 0 {
+				g.stmts = append(g.stmts, fmt.Sprintf("// … %s more …", thousands(n.elided)))
+			}
+			g.addStmt(func() string {
+				key := g.capture(e.key, n.typ.Key(), false)
+				return fmt.Sprintf("%s[%s] = %s", name, key, g.capture(e.value, n.typ.Elem(), false))
+			})
+		}
+		if n.truncated != "" {
+			g.stmts = append(g.stmts, "// … truncated ("+n.truncated+")")
+		}
+		return name
+	}
+
+	name := fmt.Sprintf("s%d", n.id)
+	g.helpers[n.id] = helper{name: name, collection: true}
+	g.decls = append(g.decls, fmt.Sprintf("%s := make(%s, %d, %d)", name, n.typ, n.len, n.cap))
+
+	if n.len > 0 && !n.folded {
+		g.addStmt(func() string {
+			items := &node{kind: nodeList, typ: n.typ, items: n.items, head: n.head, elided: n.elided, truncated: n.truncated}
+			return fmt.Sprintf("copy(%s, %s)", name, g.capture(items, nil, false))
+		})
+	}
+
+	return name
+}
+
+// addStmt appends the statement returned by `fn`, ahead of the statements added while building it.
+// The statement is built at the depth of the body of the function literal.
+func (g *goSyntax) addStmt(fn func() string) {
+	depth := g.d.depth
+	g.d.depth = 1
+
+	i := len(g.stmts)
+	g.stmts = append(g.stmts, "")
+	g.stmts[i] = fn()
+
+	g.d.depth = depth
+}
+
+// ref returns the expression of the reference `n`, which is the helper variable of the value it refers to,
+// or its address, or the value it points to.
+func (g *goSyntax) ref(n *node) string {
+	h := g.helpers[n.id]
+
+	switch {
+	case n.typ.Kind() == reflect.Pointer && h.collection:
+		return "&" + h.name
+	case n.typ.Kind() != reflect.Pointer && !h.collection:
+		return "*" + h.name
+	default:
+		return h.name
+	}
+}
+
+// sealed reports whether the struct type `t` is of the standard library and has unexported fields, which its
+// composite literals cannot set. Packages whose path does not start with a domain name are deemed standard.
+func sealed(t reflect.Type) bool {
+	path := t.PkgPath()
+	if path == "" || path == "main" || strings.Contains(strings.Split(path, "/")[0], ".") {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// goSealed returns the expression of the zero value of the sealed struct type `t`, or its address when `fn` is "new",
+// followed by a comment.
+func goSealed(fn string, t reflect.Type) string {
+	return fn + "(" + t.String() + ") /* unexported fields */"
+}
+
+// goScalar returns the Go expression of the node `n`, which is neither a pointer, a struct, a map nor a list.
+// It is converted to the type of `n` unless it is assignable to `ctx`, or it already has that type.
+func goScalar(n *node, ctx reflect.Type) string {
+	var (
+		lit   string
+		typ   reflect.Type
+		typed bool
+	)
+
+	switch n.kind {
+	case nodeNil:
+		if n.typ == nil || n.typ == ctx {
+			return "nil"
+		}
+		return goConvert(n.typ, "nil")
+	case nodeText:
+		return n.text
+	case nodePanic:
+		comment := " /* panic: " + strings.ReplaceAll(n.text, "*/", "* /") + " */"
+		if n.typ == nil {
+			return "nil" + comment
+		}
+		return "*new(" + n.typ.String() + ")" + comment
+	case nodeRedacted:
+		return "*new(" + n.typ.String() + ") /* " + redacted + " */"
+	case nodeOpaque:
+		switch n.typ.Kind() {
+		case reflect.Chan:
+			if n.cap > 0 {
+				return fmt.Sprintf("make(%s, %d)", n.typ, n.cap)
+			}
+			return fmt.Sprintf("make(%s)", n.typ)
+		default:
+			lit = "nil"
+			if n.typ != ctx {
+				lit = goConvert(n.typ, lit)
+			}
+			return lit + " /* " + n.text + " */"
+		}
+	case nodeBool:
+		lit, typ = n.text, boolType
+	case nodeString:
+		lit, typ = strconv.Quote(n.text), stringType
+	default:
+		lit, typ, typed = goNumber(n)
+	}
+
+	if ctx == n.typ && (!typed || typ == n.typ) || (ctx == nil || ctx.Kind() == reflect.Interface) && typ == n.typ {
+		return lit
+	}
+	return goConvert(n.typ, lit)
+}
+
+// goNumber returns the Go expression of the number node `n` along with its default type,
+// and whether it is a typed expression rather than an untyped constant.
+func goNumber(n *node) (lit string, typ reflect.Type, typed bool) {
+	switch n.typ.Kind() {
+	case reflect.Float32, reflect.Float64:
+		switch n.text {
+		case "NaN":
+			return "math.NaN()", float64Type, true
+		case "+Inf":
+			return "math.Inf(1)", float64Type, true
+		case "-Inf":
+			return "math.Inf(-1)", float64Type, true
+		}
+
+		if strings.ContainsAny(n.text, ".e") {
+			return n.text, float64Type, false
+		}
+		return n.text, intType, false
+	case reflect.Complex64, reflect.Complex128:
+		return n.text, complex128Type, false
+	default:
+		return n.text, intType, false
+	}
+}
+
+// goConvert returns the conversion of the expression `x` to the type `t`.
+func goConvert(t reflect.Type, x string) string {
+	s := t.String()
+	if strings.HasPrefix(s, "*") || strings.HasPrefix(s, "func") || strings.HasPrefix(s, "chan") || strings.HasPrefix(s, "<-") {
+		s = "(" + s + ")"
+	}
+	return s + "(" + x + ")"
+}
@@ -0,0 +1,227 @@
+package godump
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+)
+
+// htmlClass implements [Style] by wrapping text in a span of the class `godump-<name>`, once escaped.
+type htmlClass string
+
+func (c htmlClass) Apply(s string) string {
+	return `<span class="godump-` + string(c) + `">` + html.EscapeString(s) + `</span>`
+}
+
+// htmlTheme is the theme used to write HTML, its styles are CSS classes whose colours are set from the theme
+// of the Dumper.
+var htmlTheme = Theme{
+	String:        htmlClass("string"),
+	Quotes:        htmlClass("quotes"),
+	Bool:          htmlClass("bool"),
+	Number:        htmlClass("number"),
+	Types:         htmlClass("types"),
+	Nil:           htmlClass("nil"),
+	Func:          htmlClass("func"),
+	Chan:          htmlClass("chan"),
+	UnsafePointer: htmlClass("unsafe-pointer"),
+	Address:       htmlClass("address"),
+	PointerTag:    htmlClass("pointer-tag"),
+	Fields:        htmlClass("fields"),
+	Braces:        htmlClass("braces"),
+	Escape:        htmlClass("escape"),
+	Elision:       htmlClass("elision"),
+}
+
+const htmlStyle = `body { margin: 0; background: #1e1e1e; color: #d4d4d4; }
+.godump { margin: 0; padding: 1em; font: 14px/1.5 ui-monospace, Menlo, Consolas, monospace; }
+.godump-node > input { display: none; }
+.godump-node > label { cursor: pointer; }
+.godump-node > label:hover { text-decoration: underline; }
+.godump-node > input:checked ~ .godump-body { display: none; }
+.godump-node > input:checked ~ .godump-close::before { content: "…"; color: var(--godump-elision); }
+.godump-ref { text-decoration: none; }
+.godump-ref:hover { text-decoration: underline; }
+.godump-target { outline: 1px solid var(--godump-pointer-tag, currentColor); }
+`
+
+const htmlScript = `document.addEventListener("click", function (e) {
+  var ref = e.target.closest("a.godump-ref");
+  var target = ref && document.getElementById(ref.getAttribute("href").slice(1));
+  if (!target) return;
+  e.preventDefault();
+  for (var n = target.parentElement; n; n = n.parentElement) {
+    if (n.classList.contains("godump-node")) n.querySelector(":scope > input").checked = false;
+  }
+  document.querySelectorAll(".godump-target").forEach(function (t) { t.classList.remove("godump-target"); });
+  target.classList.add("godump-target");
+  target.scrollIntoView({ block: "center" });
+});
+`
+
+// htmlWriter writes nodes as HTML, see [FormatHTML].
+type htmlWriter struct {
+	d *renderer
+
+	// toggles is the number of collapsible values written so far.
+	toggles int
+}
+
+// writeHTMLDocument writes the node `n` as a standalone HTML document, see [FormatHTML].
+func (d *renderer) writeHTMLDocument(n *node) {
+	d.buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>godump</title>\n<style>\n")
+	d.buf.WriteString(":root {\n" + htmlColors(d.Theme) + "}\n")
+	for _, c := range themeSlots(htmlTheme) {
+		fmt.Fprintf(&d.buf, ".godump-%s { color: var(--godump-%s); }\n", c.name, c.name)
+	}
+	d.buf.WriteString(htmlStyle + "</style>\n</head>\n<body>\n<pre class=\"godump\">")
+
+	theme := d.Theme
+	d.Theme = htmlTheme
+	w := htmlWriter{d: d}
+	w.write(n)
+	d.Theme = theme
+
+	d.buf.WriteString("</pre>\n<script>\n" + htmlScript + "</script>\n</body>\n</html>")
+}
+
+// themeSlot is a style of a [Theme] along with the name of the CSS class it is mapped to.
+type themeSlot struct {
+	name  string
+	style Style
+}
+
+// themeSlots returns the styles of `t` used to print values, in the order they are declared.
+func themeSlots(t Theme) []themeSlot {
+	return []themeSlot{
+		{"string", t.String},
+		{"quotes", t.Quotes},
+		{"bool", t.Bool},
+		{"number", t.Number},
+		{"types", t.Types},
+		{"nil", t.Nil},
+		{"func", t.Func},
+		{"chan", t.Chan},
+		{"unsafe-pointer", t.UnsafePointer},
+		{"address", t.Address},
+		{"pointer-tag", t.PointerTag},
+		{"fields", t.Fields},
+		{"braces", t.Braces},
+		{"escape", t.Escape},
+		{"elision", t.Elision},
+	}
+}
+
+// htmlColors returns the declarations of the CSS variables holding the colours of the theme `t`.
+// Only [RGB] styles can be mapped to colours, the others are left to the default colour.
+func htmlColors(t Theme) string {
+	var b strings.Builder
+	for _, c := range themeSlots(t) {
+		if rgb, ok := c.style.(RGB); ok {
+			fmt.Fprintf(&b, "  --godump-%s: rgb(%d, %d, %d);\n", c.name, rgb.R, rgb.G, rgb.B)
+		}
+	}
+	return b.String()
+}
+
+// write writes the node `n` the way the text format would, with collapsible structs, maps and slices,
+// and pointer tags linking references to the values they refer to.
+func (w *htmlWriter) write(n *node) {
+	d := w.d
+	d.flush()
+
+	switch n.kind {
+	case nodeRef:
+		if n.typ.Kind() == reflect.Pointer {
+			d.buf.WriteString(__(d.Theme.Address, "&"))
+		}
+		fmt.Fprintf(&d.buf, `<a class="godump-ref godump-pointer-tag" href="#godump-%d">@%d</a>`, n.id, n.id)
+	case nodePointer:
+		if n.elem.kind != nodeNil || n.elem.typ != nil {
+			d.buf.WriteString(__(d.Theme.Address, "&"))
+		}
+		if n.id == 0 || n.elem.id == n.id {
+			w.write(n.elem)
+			return
+		}
+		fmt.Fprintf(&d.buf, `<span id="godump-%d">`, n.id)
+		w.write(n.elem)
+		d.buf.WriteString("</span>")
+	case nodeStruct:
+		summary := ""
+		if n.folded {
+			summary = fmt.Sprintf("%d fields", d.countFields(n.typ))
+		}
+		w.writeComposite(n, header(n), summary, len(n.fields), func(i int) {
+			d.buf.WriteString(__(d.Theme.Fields, n.fields[i].name) + ": ")
+			w.write(n.fields[i].value)
+		})
+	case nodeMap:
+		w.writeComposite(n, header(n), "", len(n.entries), func(i int) {
+			w.write(n.entries[i].key)
+			d.buf.WriteString(": ")
+			w.write(n.entries[i].value)
+		})
+	case nodeList:
+		w.writeComposite(n, header(n), "", len(n.items), func(i int) {
+			w.write(n.items[i])
+		})
+	default:
+		d.writeLeaf(n)
+	}
+}
+
+// writeComposite writes the struct, map or list node `n` made of `count` elements written by `element`.
+// Its header can be clicked to collapse it, and its elements are preceded by the marker of the elided ones, and
+// followed by the marker of its truncation.
+func (w *htmlWriter) writeComposite(n *node, typ, summary string, count int, element func(i int)) {
+	d := w.d
+
+	var tag string
+	if n.id != 0 {
+		tag = fmt.Sprintf(`<a class="godump-pointer-tag" id="godump-%d">#%d</a>`, n.id, n.id)
+	}
+
+	if n.folded || count == 0 && n.elided == 0 && n.truncated == "" {
+		d.buf.WriteString(__(d.Theme.Types, typ) + __(d.Theme.Braces, " {") + tag)
+		if n.folded {
+			if tag != "" {
+				d.buf.WriteString(" ")
+			}
+			d.buf.WriteString(__(d.Theme.Elision, "…"+summary))
+		}
+		d.buf.WriteString(__(d.Theme.Braces, "}"))
+		return
+	}
+
+	w.toggles++
+	fmt.Fprintf(&d.buf, `<span class="godump-node"><input type="checkbox" id="godump-toggle-%d">`, w.toggles)
+	fmt.Fprintf(&d.buf, `<label for="godump-toggle-%d">`, w.toggles)
+	d.buf.WriteString(__(d.Theme.Types, typ) + __(d.Theme.Braces, " {") + "</label>" + tag)
+	d.buf.WriteString(`<span class="godump-body">`)
+
+	d.depth++
+	for i := 0; i <= count; i++ {
+		if i == n.head && n.elided > 0 {
+			d.writeElision(n.elided)
+		}
+
+		if i < count {
+			d.buf.WriteString("\n")
+			d.indent()
+			element(i)
+			d.buf.WriteString(",")
+		}
+	}
+	if n.truncated != "" {
+		d.buf.WriteString("\n")
+		d.indent()
+		d.buf.WriteString(__(d.Theme.Elision, "… truncated ("+n.truncated+")"))
+	}
+	d.depth--
+
+	d.buf.WriteString("\n")
+	d.indent()
+	d.buf.WriteString(`</span><span class="godump-close">` + __(d.Theme.Braces, "}") + "</span></span>")
+}
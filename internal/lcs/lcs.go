@@ -0,0 +1,92 @@
+// Package lcs aligns two sequences along their longest common subsequence, so that the elements inserted
+// or removed from one to the other can be told apart from the ones kept.
+package lcs
+
+// Op is an operation of an alignment, it is written as the marker of the lines of a diff.
+type Op byte
+
+const (
+	// Keep is an element found in both sequences.
+	Keep Op = ' '
+
+	// Remove is an element found only in the first sequence.
+	Remove Op = '-'
+
+	// Insert is an element found only in the second sequence.
+	Insert Op = '+'
+)
+
+// Align returns the operations turning the sequence `x` of length `n` into the sequence `y` of length `m`,
+// along their longest common subsequence. Elements are compared by `equal`, which reports whether x[i] and y[j]
+// are the same. [Keep] consumes an element of both sequences, [Remove] one of `x` and [Insert] one of `y`.
+//
+// The common prefix and suffix are kept as is. If aligning the elements between them takes more than `limit`
+// comparisons, they are all removed then all inserted instead.
+func Align(n, m, limit int, equal func(i, j int) bool) []Op {
+	var start int
+	for start < n && start < m && equal(start, start) {
+		start++
+	}
+
+	var end int
+	for end < n-start && end < m-start && equal(n-1-end, m-1-end) {
+		end++
+	}
+
+	ops := make([]Op, 0, max(n, m))
+	for i := 0; i < start; i++ {
+		ops = append(ops, Keep)
+	}
+	ops = align(ops, start, n-end, start, m-end, limit, equal)
+	for i := 0; i < end; i++ {
+		ops = append(ops, Keep)
+	}
+	return ops
+}
+
+// align appends the operations aligning x[i0:i1] and y[j0:j1] to `ops`, see [Align].
+func align(ops []Op, i0, i1, j0, j1, limit int, equal func(i, j int) bool) []Op {
+	n, m := i1-i0, j1-j0
+
+	if n*m > limit {
+		for i := 0; i < n; i++ {
+			ops = append(ops, Remove)
+		}
+		for j := 0; j < m; j++ {
+			ops = append(ops, Insert)
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i0+i:i1] and y[j0+j:j1].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(i0+i, j0+j) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && equal(i0+i, j0+j):
+			ops = append(ops, Keep)
+			i++
+			j++
+		case j == m || i < n && lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, Remove)
+			i++
+		default:
+			ops = append(ops, Insert)
+			j++
+		}
+	}
+	return ops
+}
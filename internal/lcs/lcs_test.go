@@ -0,0 +1,32 @@
+package lcs_test
+
+import (
+	"testing"
+
+	"github.com/yassinebenaid/godump/internal/lcs"
+)
+
+func TestAlign(t *testing.T) {
+	cases := []struct {
+		x, y     string
+		limit    int
+		expected string
+	}{
+		{x: "", y: "", limit: 16, expected: ""},
+		{x: "abc", y: "abc", limit: 16, expected: "   "},
+		{x: "abc", y: "", limit: 16, expected: "---"},
+		{x: "", y: "abc", limit: 16, expected: "+++"},
+		{x: "abcd", y: "axcd", limit: 16, expected: " -+  "},
+		{x: "abcde", y: "bce", limit: 16, expected: "-  - "},
+		{x: "axbyc", y: "abzc", limit: 16, expected: " - -+ "},
+		{x: "axbyc", y: "abzc", limit: 6, expected: " - -+ "},
+		{x: "axbyc", y: "abzc", limit: 5, expected: " ---++ "},
+	}
+
+	for _, c := range cases {
+		ops := lcs.Align(len(c.x), len(c.y), c.limit, func(i, j int) bool { return c.x[i] == c.y[j] })
+		if got := string(ops); got != c.expected {
+			t.Errorf("unexpected alignment of %q and %q with a limit of %d: %q, expected %q", c.x, c.y, c.limit, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package godump
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// member is a member of a JSON object, its value is either the node `value` or the raw JSON `raw`.
+type member struct {
+	key   string
+	raw   string
+	value *node
+}
+
+// writeJSON writes the node `n` as indented JSON, see [Dumper.Format].
+func (d *renderer) writeJSON(n *node) {
+	d.flush()
+
+	switch n.kind {
+	case nodeNil:
+		d.buf.WriteString("null")
+	case nodeBool:
+		d.buf.WriteString(n.text)
+	case nodeNumber:
+		if jsonNumber(n) {
+			d.buf.WriteString(n.text)
+		} else {
+			d.buf.WriteString(jsonQuote(n.text))
+		}
+	case nodeString, nodeOpaque:
+		d.buf.WriteString(jsonQuote(n.text))
+	case nodeText:
+		d.writeJSONObject([]member{jsonType(n.typ), {key: "$value", raw: jsonQuote(n.text)}})
+	case nodeRef:
+		d.buf.WriteString(fmt.Sprintf(`{"$ref": %d}`, n.id))
+	case nodePanic:
+		d.buf.WriteString(`{"$panic": ` + jsonQuote(n.text) + `}`)
+	case nodeRedacted:
+		d.writeJSONObject([]member{jsonType(n.typ), {key: "$value", raw: jsonQuote(redacted)}})
+	case nodePointer:
+		if n.id == 0 || n.elem.id == n.id {
+			d.writeJSON(n.elem)
+			return
+		}
+		d.writeJSONObject([]member{jsonType(n.typ), jsonID(n.id), {key: "$value", value: n.elem}})
+	case nodeStruct:
+		members := jsonHeader(n)
+		for _, f := range n.fields {
+			members = append(members, member{key: f.name, value: f.value})
+		}
+		d.writeJSONObject(append(members, jsonTruncation(n)...))
+	case nodeMap:
+		d.writeJSONMap(n)
+	case nodeList:
+		if n.id == 0 && !n.folded {
+			d.writeJSONArray(n)
+			return
+		}
+
+		members := jsonHeader(n)
+		if !n.folded {
+			items := &node{kind: nodeList, items: n.items, head: n.head, elided: n.elided, truncated: n.truncated}
+			members = append(members, member{key: "$items", value: items})
+		}
+		d.writeJSONObject(members)
+	}
+}
+
+// writeJSONMap writes the map node `n` as an object keyed by its keys when they are strings, numbers or booleans,
+// or as an object holding the list of its entries otherwise.
+func (d *renderer) writeJSONMap(n *node) {
+	members := jsonHeader(n)
+	if n.folded {
+		d.writeJSONObject(members)
+		return
+	}
+
+	if !jsonKeys(n) {
+		entries := make([]*node, len(n.entries))
+		for i, e := range n.entries {
+			entries[i] = &node{kind: nodeList, items: []*node{e.key, e.value}}
+		}
+		list := &node{kind: nodeList, items: entries, head: n.head, elided: n.elided, truncated: n.truncated}
+		d.writeJSONObject(append(members, member{key: "$entries", value: list}))
+		return
+	}
+
+	for i, e := range n.entries {
+		if i == n.head && n.elided > 0 {
+			members = append(members, member{key: "$elided", raw: strconv.Itoa(n.elided)})
+		}
+
+		key := e.key.text
+		if strings.HasPrefix(key, "$") {
+			key = "$" + key
+		}
+		members = append(members, member{key: key, value: e.value})
+	}
+	d.writeJSONObject(append(members, jsonTruncation(n)...))
+}
+
+// writeJSONObject writes the object made of `members`, one per line.
+func (d *renderer) writeJSONObject(members []member) {
+	if len(members) == 0 {
+		d.buf.WriteString("{}")
+		return
+	}
+
+	d.buf.WriteString("{")
+	d.depth++
+	for i, m := range members {
+		if i > 0 {
+			d.buf.WriteString(",")
+		}
+		d.buf.WriteString("\n")
+		d.indent()
+		d.buf.WriteString(jsonQuote(m.key) + ": ")
+		if m.value != nil {
+			d.writeJSON(m.value)
+		} else {
+			d.buf.WriteString(m.raw)
+		}
+	}
+	d.depth--
+	d.buf.WriteString("\n")
+	d.indent()
+	d.buf.WriteString("}")
+}
+
+// writeJSONArray writes the items of the list node `n` as an array, one per line, along with a marker of the items
+// elided after the first `head` ones, and a marker of the truncation of the list if any.
+func (d *renderer) writeJSONArray(n *node) {
+	items, head, elided := n.items, n.head, n.elided
+	if len(items) == 0 && elided == 0 && n.truncated == "" {
+		d.buf.WriteString("[]")
+		return
+	}
+
+	d.buf.WriteString("[")
+	d.depth++
+	for i := 0; i <= len(items); i++ {
+		if i == head && elided > 0 {
+			d.nextJSONItem(i == 0)
+			d.buf.WriteString(fmt.Sprintf(`{"$elided": %d}`, elided))
+		}
+
+		if i < len(items) {
+			d.nextJSONItem(i == 0 && (head != 0 || elided == 0))
+			d.writeJSON(items[i])
+		}
+	}
+	if n.truncated != "" {
+		d.nextJSONItem(len(items) == 0 && elided == 0)
+		d.buf.WriteString(`{"$truncated": ` + jsonQuote(n.truncated) + `}`)
+	}
+	d.depth--
+	d.buf.WriteString("\n")
+	d.indent()
+	d.buf.WriteString("]")
+}
+
+// nextJSONItem starts a new line for the next item of an array, preceded by a comma unless it is the first one.
+func (d *renderer) nextJSONItem(first bool) {
+	if !first {
+		d.buf.WriteString(",")
+	}
+	d.buf.WriteString("\n")
+	d.indent()
+}
+
+// jsonHeader returns the members every object of a struct, map or slice starts with.
+func jsonHeader(n *node) []member {
+	members := []member{jsonType(n.typ)}
+	if n.id != 0 {
+		members = append(members, jsonID(n.id))
+	}
+	if n.folded {
+		members = append(members, member{key: "$folded", raw: "true"})
+	}
+	return members
+}
+
+// jsonTruncation returns the member marking the truncation of the struct or map node `n`, if any.
+func jsonTruncation(n *node) []member {
+	if n.truncated == "" {
+		return nil
+	}
+	return []member{{key: "$truncated", raw: jsonQuote(n.truncated)}}
+}
+
+func jsonType(t reflect.Type) member {
+	return member{key: "$type", raw: jsonQuote(t.String())}
+}
+
+func jsonID(id uint) member {
+	return member{key: "$id", raw: fmt.Sprint(id)}
+}
+
+// jsonKeys reports whether the keys of the map node `n` can be used as the keys of a JSON object.
+func jsonKeys(n *node) bool {
+	switch n.typ.Key().Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return false
+	}
+
+	for _, e := range n.entries {
+		if e.key.kind != nodeString && e.key.kind != nodeNumber && e.key.kind != nodeBool {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonNumber reports whether the number node `n` can be written as a JSON number.
+func jsonNumber(n *node) bool {
+	switch n.typ.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(n.text, 64)
+		return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+	case reflect.Complex64, reflect.Complex128, reflect.Uintptr:
+		return false
+	default:
+		return true
+	}
+}
+
+// jsonQuote returns `s` as a JSON string, invalid UTF-8 bytes are replaced by U+FFFD.
+func jsonQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < ' ' || r == 0x7f || r == '\u2028' || r == '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
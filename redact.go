@@ -0,0 +1,85 @@
+package godump
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// SensitiveNames matches the names of struct fields and map keys that commonly hold credentials,
+// it is meant to be used with [Dumper.Redact].
+var SensitiveNames = regexp.MustCompile(`(?i)password|passwd|token|secret|api_?key|credential|private_?key`)
+
+// redacted is printed in place of the values of redacted fields and map entries.
+const redacted = "[REDACTED]"
+
+// redactsField reports whether the value of the struct field `f` is redacted, which is the case when it is
+// tagged `dump:"redact"`, or when its name matches one of the patterns of [Dumper.Redact].
+func (d *renderer) redactsField(f reflect.StructField) bool {
+	if d.reveal {
+		return false
+	}
+	for _, opt := range strings.Split(f.Tag.Get("dump"), ",") {
+		if strings.TrimSpace(opt) == "redact" {
+			return true
+		}
+	}
+	return d.redactsName(f.Name)
+}
+
+// redactsKey reports whether the value of the map entry having the key `k` is redacted, which is the case
+// when the key is a string matching one of the patterns of [Dumper.Redact].
+func (d *renderer) redactsKey(k reflect.Value) bool {
+	if d.reveal {
+		return false
+	}
+	for k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+	return k.Kind() == reflect.String && d.redactsName(k.String())
+}
+
+func (d *renderer) redactsName(name string) bool {
+	for _, re := range d.Redact {
+		if re != nil && re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedType returns the type printed along with the redacted value `v`, which is its dynamic type
+// if it is a non-nil interface. The value itself is never read.
+func redactedType(v reflect.Value) reflect.Type {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.Type()
+}
+
+// buildRedacted returns the node of the redacted value `v`, which has a fingerprint if the tree is built
+// for [Dumper.Fdiff], so that changes to it are still found.
+func (d *renderer) buildRedacted(v reflect.Value) *node {
+	n := &node{kind: nodeRedacted, typ: redactedType(v)}
+	if d.diffing {
+		n.hidden = d.fingerprint(v)
+	}
+	return n
+}
+
+// fingerprint returns the plain text of the redacted value `v`, as printed by the text format with the same
+// options, nested redacted values included. It is only ever compared.
+func (d *renderer) fingerprint(v reflect.Value) string {
+	r := &renderer{Dumper: d.Dumper, reveal: true}
+	r.Theme = Theme{}
+	r.init(nil)
+	r.scan(v, make(map[ref]bool), d.depth)
+	r.depth = d.depth
+	r.dump(v, true)
+	return r.buf.String()
+}
+
+// writeRedacted writes the type `t` followed by the redaction marker.
+func (d *renderer) writeRedacted(t reflect.Type) {
+	d.buf.WriteString(__(d.typeStyle(t), t.String()) + " " + __(d.Theme.Elision, redacted))
+}
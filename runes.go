@@ -0,0 +1,107 @@
+package godump
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	runeType = reflect.TypeOf(rune(0))
+	byteType = reflect.TypeOf(byte(0))
+)
+
+// isRunes reports whether `v` is a slice or an array of runes.
+func isRunes(v reflect.Value) bool {
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem() == runeType
+}
+
+// char returns the styled number `v` of type rune or byte, followed by the character it represents, eg., 97 'a'.
+// Runes outside of the ASCII range are printed in hex, eg., 0x1F600 '😀'.
+func (d *renderer) char(v reflect.Value) string {
+	var num, char string
+
+	if v.Type() == byteType {
+		b := byte(v.Uint())
+		num = fmt.Sprint(b)
+		if b >= utf8.RuneSelf {
+			char = __(d.Theme.Escape, fmt.Sprintf(`\x%02x`, b))
+		} else {
+			char = d.escapeChar(rune(b))
+		}
+	} else {
+		r := rune(v.Int())
+		num = fmt.Sprint(r)
+		if r >= utf8.RuneSelf {
+			num = fmt.Sprintf("0x%X", r)
+		}
+		char = d.escapeChar(r)
+	}
+
+	return __(d.Theme.Number, num) + " " + __(d.Theme.Quotes, "'") + char + __(d.Theme.Quotes, "'")
+}
+
+// escapeChar returns the styled character `r`, escaped according to [Dumper.Escaping].
+func (d *renderer) escapeChar(r rune) string {
+	switch {
+	case !utf8.ValidRune(r):
+		return __(d.Theme.Escape, invalidRune(r))
+	case r == '\'' && d.Escaping == EscapeQuoted:
+		return __(d.Theme.Escape, `\'`)
+	default:
+		return d.escape(string(r), true)
+	}
+}
+
+// dumpRunes writes the rune slice or array `v` as a quoted string, after its type.
+// Invalid code points are escaped, eg., '\ud800'.
+func (d *renderer) dumpRunes(v reflect.Value, tag string) {
+	runes := make([]rune, v.Len())
+	for i := range runes {
+		runes[i] = rune(v.Index(i).Int())
+	}
+
+	var str string
+	if validRunes(runes) {
+		str = d.quote(string(runes))
+	} else {
+		var b strings.Builder
+		b.WriteString(__(d.Theme.Quotes, `"`))
+
+		start := 0
+		for i, r := range runes {
+			if utf8.ValidRune(r) {
+				continue
+			}
+			b.WriteString(d.escape(string(runes[start:i]), false))
+			b.WriteString(__(d.Theme.Escape, invalidRune(r)))
+			start = i + 1
+		}
+		b.WriteString(d.escape(string(runes[start:]), false))
+
+		b.WriteString(__(d.Theme.Quotes, `"`))
+		str = b.String()
+	}
+
+	d.buf.WriteString(__(d.Theme.Braces, "(") + str + __(d.Theme.Braces, ")"))
+	d.buf.WriteString(tag)
+}
+
+// validRunes reports whether all of `runes` are valid Unicode code points.
+func validRunes(runes []rune) bool {
+	for _, r := range runes {
+		if !utf8.ValidRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidRune returns the escape sequence of the invalid code point `r`.
+func invalidRune(r rune) string {
+	if r >= 0 && r <= 0xffff {
+		return fmt.Sprintf(`\u%04x`, r)
+	}
+	return fmt.Sprintf(`\U%08x`, uint32(r))
+}
@@ -0,0 +1,206 @@
+package godump
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+)
+
+// entry is a key-value pair of a map.
+type entry struct {
+	key, value reflect.Value
+}
+
+// sortEntries sorts the map entries `entries` by key, then by value for keys that compare equal, eg., NaN,
+// in place. It gives up once `stop` reports true, leaving them partly sorted.
+//
+// Numbers are sorted in numeric order, strings in lexical order and booleans with false first.
+// Structs and arrays are compared item by item, and pointers by the values they point to, so that the order is
+// the same from one run to another. Pointers to equal values, and channels, are compared by address.
+// Interfaces holding values of different types are ordered as nil, booleans, numbers, strings,
+// then everything else grouped by type.
+func sortEntries(entries []entry, stop func() bool) {
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		if stop() {
+			return 0
+		}
+		return compareEntries(a, b)
+	})
+}
+
+// compareEntries compares the map entries `a` and `b` by key, then by value, see [sortEntries].
+func compareEntries(a, b entry) int {
+	if c := compare(a.key, b.key); c != 0 {
+		return c
+	}
+	return compare(a.value, b.value)
+}
+
+// entryHeap keeps the `size` least of the map entries pushed to it in the order of `cmp`, without sorting them all.
+// It is a max-heap, so that its root is the first entry to be left out.
+type entryHeap struct {
+	entries []entry
+	size    int
+	cmp     func(a, b entry) int
+}
+
+// push adds the entry `e` to the heap. If the heap is full, the greatest of its entries and `e` is left out
+// and returned.
+func (h *entryHeap) push(e entry) (out entry, ok bool) {
+	if len(h.entries) < h.size {
+		h.entries = append(h.entries, e)
+		for i := len(h.entries) - 1; i > 0; {
+			p := (i - 1) / 2
+			if h.cmp(h.entries[i], h.entries[p]) <= 0 {
+				break
+			}
+			h.entries[i], h.entries[p] = h.entries[p], h.entries[i]
+			i = p
+		}
+		return entry{}, false
+	}
+
+	if h.size == 0 || h.cmp(e, h.entries[0]) >= 0 {
+		return e, true
+	}
+
+	out, h.entries[0] = h.entries[0], e
+	for i := 0; ; {
+		c := 2*i + 1
+		if c >= len(h.entries) {
+			break
+		}
+		if c+1 < len(h.entries) && h.cmp(h.entries[c+1], h.entries[c]) > 0 {
+			c++
+		}
+		if h.cmp(h.entries[c], h.entries[i]) <= 0 {
+			break
+		}
+		h.entries[i], h.entries[c] = h.entries[c], h.entries[i]
+		i = c
+	}
+	return out, true
+}
+
+// sorted returns the entries of the heap, sorted in the order of `cmp`.
+func (h *entryHeap) sorted() []entry {
+	slices.SortStableFunc(h.entries, h.cmp)
+	return h.entries
+}
+
+// compare returns -1 if `a` is less than `b`, 1 if `a` is greater than `b`, and 0 otherwise.
+func compare(a, b reflect.Value) int {
+	return compareIn(a, b, nil)
+}
+
+// compareIn compares `a` and `b` like [compare] does. `visiting` holds the pairs of pointers whose values are being
+// compared, those found again are part of a cycle and are compared by address instead.
+func compareIn(a, b reflect.Value, visiting map[[2]uintptr]bool) int {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+
+	switch {
+	case !a.IsValid() || !b.IsValid():
+		return cmp.Compare(btoi(a.IsValid()), btoi(b.IsValid()))
+	case a.Type() != b.Type():
+		if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+			return c
+		}
+		if x, ok := number(a); ok {
+			y, _ := number(b)
+			if c := cmp.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.Type().String(), b.Type().String())
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		if c := cmp.Compare(real(a.Complex()), real(b.Complex())); c != 0 {
+			return c
+		}
+		return cmp.Compare(imag(a.Complex()), imag(b.Complex()))
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp.Compare(btoi(a.Bool()), btoi(b.Bool()))
+	case reflect.Pointer:
+		if p := [2]uintptr{a.Pointer(), b.Pointer()}; p[0] != 0 && p[1] != 0 && p[0] != p[1] && !visiting[p] {
+			if visiting == nil {
+				visiting = make(map[[2]uintptr]bool)
+			}
+
+			visiting[p] = true
+			c := compareIn(a.Elem(), b.Elem(), visiting)
+			delete(visiting, p)
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.Pointer(), b.Pointer())
+	case reflect.Chan, reflect.UnsafePointer:
+		return cmp.Compare(a.Pointer(), b.Pointer())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if c := compareIn(a.Field(i), b.Field(i), visiting); c != 0 {
+				return c
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if c := compareIn(a.Index(i), b.Index(i), visiting); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return 0
+}
+
+// rank returns the position of the group `v` belongs to when sorting values of different types.
+func rank(v reflect.Value) int {
+	if _, ok := number(v); ok {
+		return 2
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return 1
+	case reflect.String:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// number returns the value of the integer or float `v` as a float64.
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
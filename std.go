@@ -0,0 +1,193 @@
+package godump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// stdFormatters are the formatters of standard library types, used when [Dumper.UseStdFormatters] is set.
+// Their Go syntax is used by [FormatGo] regardless.
+var stdFormatters = []formatter{
+	std(reflect.TypeOf(time.Time{}), "time.Time", styleQuoted, func(v any) string {
+		t := v.(time.Time)
+		return t.Format(time.RFC3339Nano) + " " + t.Location().String()
+	}).withSyntax(func(v any) string {
+		t := v.(time.Time)
+		return fmt.Sprintf("time.Date(%d, time.%s, %d, %d, %d, %d, %d, %s)",
+			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), locationSyntax(t))
+	}),
+	std(reflect.TypeOf(time.Duration(0)), "time.Duration", styleNumber, func(v any) string {
+		return v.(time.Duration).String()
+	}),
+	std(reflect.TypeOf(big.Int{}), "big.Int", styleNumber, func(v any) string {
+		x := v.(big.Int)
+		return x.String()
+	}).withSyntax(func(v any) string {
+		x := v.(big.Int)
+		return "*" + bigIntSyntax(&x)
+	}),
+	std(reflect.TypeOf(&big.Int{}), "big.Int", styleNumber, func(v any) string {
+		return v.(*big.Int).String()
+	}).withSyntax(func(v any) string {
+		return bigIntSyntax(v.(*big.Int))
+	}),
+	std(reflect.TypeOf(big.Float{}), "big.Float", styleNumber, func(v any) string {
+		x := v.(big.Float)
+		return x.Text('g', -1)
+	}).withSyntax(func(v any) string {
+		x := v.(big.Float)
+		return "*" + bigFloatSyntax(&x)
+	}),
+	std(reflect.TypeOf(&big.Float{}), "big.Float", styleNumber, func(v any) string {
+		return v.(*big.Float).Text('g', -1)
+	}).withSyntax(func(v any) string {
+		return bigFloatSyntax(v.(*big.Float))
+	}),
+	std(reflect.TypeOf(net.IP{}), "net.IP", styleNumber, func(v any) string {
+		return v.(net.IP).String()
+	}),
+	std(reflect.TypeOf(netip.Addr{}), "netip.Addr", styleNumber, func(v any) string {
+		return v.(netip.Addr).String()
+	}).withSyntax(func(v any) string {
+		if a := v.(netip.Addr); a.IsValid() {
+			return fmt.Sprintf("netip.MustParseAddr(%q)", a.String())
+		}
+		return "netip.Addr{}"
+	}),
+	std(reflect.TypeOf(url.URL{}), "url.URL", styleQuoted, func(v any) string {
+		u := v.(url.URL)
+		return u.String()
+	}).withSyntax(func(v any) string {
+		u := v.(url.URL)
+		return "*" + urlSyntax(&u)
+	}),
+	std(reflect.TypeOf(&url.URL{}), "url.URL", styleQuoted, func(v any) string {
+		return v.(*url.URL).String()
+	}).withSyntax(func(v any) string {
+		return urlSyntax(v.(*url.URL))
+	}),
+	std(reflect.TypeOf(json.RawMessage{}), "json.RawMessage", styleJSON, func(v any) string {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, v.(json.RawMessage)); err != nil {
+			return string(v.(json.RawMessage))
+		}
+		return buf.String()
+	}).withSyntax(func(v any) string {
+		return fmt.Sprintf("json.RawMessage(%q)", string(v.(json.RawMessage)))
+	}),
+	std(reflect.TypeOf((*reflect.Type)(nil)).Elem(), "reflect.Type", styleType, func(v any) string {
+		return v.(reflect.Type).String()
+	}).withSyntax(func(v any) string {
+		return fmt.Sprintf("reflect.TypeOf((*%s)(nil)).Elem()", v.(reflect.Type))
+	}),
+	std(reflect.TypeOf(regexp.Regexp{}), "regexp.Regexp", styleQuoted, func(v any) string {
+		re := v.(regexp.Regexp)
+		return re.String()
+	}).withSyntax(func(v any) string {
+		re := v.(regexp.Regexp)
+		return fmt.Sprintf("*regexp.MustCompile(%q)", re.String())
+	}),
+	std(reflect.TypeOf(&regexp.Regexp{}), "regexp.Regexp", styleQuoted, func(v any) string {
+		return v.(*regexp.Regexp).String()
+	}).withSyntax(func(v any) string {
+		return fmt.Sprintf("regexp.MustCompile(%q)", v.(*regexp.Regexp).String())
+	}),
+}
+
+// std returns the formatter of the standard library type `t`, which prints the text of values returned by `text`
+// styled using `style`, annotated with `label`.
+func std(t reflect.Type, label string, style func(w *Writer, s string) string, text func(v any) string) formatter {
+	ptr := t.Kind() == reflect.Pointer
+
+	return formatter{
+		typ: t,
+		fn: func(w *Writer, v any) {
+			w.label(ptr, label, style(w, text(v)))
+		},
+		text: text,
+	}
+}
+
+// withSyntax returns a copy of `f` printing the Go expression returned by `syntax` with [FormatGo].
+func (f formatter) withSyntax(syntax func(v any) string) formatter {
+	f.syntax = syntax
+	return f
+}
+
+// bigIntSyntax returns the Go expression of `x`.
+func bigIntSyntax(x *big.Int) string {
+	if x.IsInt64() {
+		return fmt.Sprintf("big.NewInt(%d)", x.Int64())
+	}
+	return fmt.Sprintf("func() *big.Int { x, _ := new(big.Int).SetString(%q, 10); return x }()", x.String())
+}
+
+// bigFloatSyntax returns the Go expression of `x`, which keeps its precision and rounding mode.
+func bigFloatSyntax(x *big.Float) string {
+	if x.Prec() == 0 {
+		return "new(big.Float)"
+	}
+	if f, acc := x.Float64(); acc == big.Exact && x.Prec() == 53 && x.Mode() == big.ToNearestEven && !x.IsInf() {
+		return fmt.Sprintf("big.NewFloat(%s)", strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return fmt.Sprintf("func() *big.Float { x, _, _ := big.ParseFloat(%q, 10, %d, big.%s); return x }()",
+		x.Text('g', -1), x.Prec(), x.Mode())
+}
+
+// urlSyntax returns the Go expression of `u`.
+func urlSyntax(u *url.URL) string {
+	return fmt.Sprintf("func() *url.URL { u, _ := url.Parse(%q); return u }()", u.String())
+}
+
+// locationSyntax returns the Go expression of the location of `t`, zones other than UTC and Local
+// are fixed to the offset in effect at `t`.
+func locationSyntax(t time.Time) string {
+	switch t.Location() {
+	case time.UTC:
+		return "time.UTC"
+	case time.Local:
+		return "time.Local"
+	default:
+		name, offset := t.Zone()
+		return fmt.Sprintf("time.FixedZone(%q, %d)", name, offset)
+	}
+}
+
+func styleQuoted(w *Writer, s string) string {
+	return w.d.quote(s)
+}
+
+func styleNumber(w *Writer, s string) string {
+	return __(w.Theme.Number, s)
+}
+
+func styleType(w *Writer, s string) string {
+	return __(w.Theme.Types, s)
+}
+
+func styleJSON(w *Writer, s string) string {
+	if !json.Valid([]byte(s)) {
+		return w.d.quote(s)
+	}
+	return __(w.Theme.String, s)
+}
+
+// label writes the already styled value `s` annotated with its type `typ`, prefixed with '&' for pointers.
+func (w *Writer) label(ptr bool, typ, s string) {
+	if ptr {
+		w.Write(w.Theme.Address, "&")
+	}
+	w.Write(w.Theme.Types, typ)
+	w.Write(w.Theme.Braces, "(")
+	w.Write(nil, s)
+	w.Write(w.Theme.Braces, ")")
+}
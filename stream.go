@@ -0,0 +1,64 @@
+package godump
+
+import (
+	"fmt"
+	"io"
+)
+
+// streamChunk is the size of the chunks written by [Dumper.Stream].
+const streamChunk = 32 << 10
+
+// Stream formats `v` and writes the result to `dst` as it goes, rather than once the whole output is ready.
+//
+// The output is written in chunks of a few kilobytes, so that it shows up early and is not held in memory
+// as a whole. Formats other than [FormatText] still hold the tree of values they are rendered from, and
+// [FormatGo] holds values referred to several times until they are declared.
+//
+// Formatting stops at the first write error, which is returned along with the number of bytes written.
+func (d *Dumper) Stream(dst io.Writer, v any) (int64, error) {
+	r := d.newRenderer()
+	r.dst = dst
+
+	r.render(v)
+	r.write()
+	if r.err != nil {
+		return r.written, fmt.Errorf("dumper error: encountered unexpected write error, %w", r.err)
+	}
+	return r.written, nil
+}
+
+// flush writes the buffer to the destination of [Dumper.Stream] once it holds a chunk, unless some output
+// is being captured.
+func (d *renderer) flush() {
+	if d.dst != nil && d.captures == 0 && d.buf.Len() >= streamChunk {
+		d.write()
+	}
+}
+
+// write writes the buffer to the destination of [Dumper.Stream]. The first write error is kept, which stops
+// the traversal, and the buffer is discarded from then on.
+func (d *renderer) write() {
+	if d.err != nil {
+		d.buf.Reset()
+		return
+	}
+
+	n, err := d.buf.WriteTo(d.dst)
+	d.written += n
+	d.err = err
+}
+
+// capture returns what `fn` writes instead of writing it. The buffer is not flushed meanwhile.
+func (d *renderer) capture(fn func()) (s string) {
+	start := d.buf.Len()
+
+	d.captures++
+	defer func() {
+		d.captures--
+		s = d.buf.String()[start:]
+		d.buf.Truncate(start)
+	}()
+
+	fn()
+	return s
+}
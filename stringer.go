@@ -0,0 +1,112 @@
+package godump
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// stringer returns the result of the Error, String or GoString method of `v`, it reports false if `v` has none of them.
+//
+// Values obtained from unexported fields are accessed through their address, see [fieldOf], or ignored if they
+// are not addressable. Interfaces and nil values are ignored as well. Methods that panic are not recovered from, so that the value
+// is printed as a `<panic: …>` placeholder by the caller.
+func stringer(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Interface || isNil(v) {
+		return "", false
+	}
+
+	v, ok := exported(v)
+	if !ok {
+		return "", false
+	}
+
+	if s, ok := callStringer(v.Interface()); ok {
+		return s, true
+	}
+
+	if v.CanAddr() {
+		return callStringer(v.Addr().Interface())
+	}
+	return "", false
+}
+
+// exported returns a copy of `v` that can be used without panicking through [reflect.Value.Interface].
+// Values obtained from unexported fields are accessed through their address, it reports false if they are not addressable.
+func exported(v reflect.Value) (reflect.Value, bool) {
+	if v.CanInterface() {
+		return v, true
+	}
+
+	if !v.CanAddr() {
+		return v, false
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem(), true
+}
+
+// fieldOf returns the field `i` of the struct `v`. Unexported fields of addressable structs are accessed through
+// their address, so that they and the values they hold, eg., map values or the values of interfaces, can be used
+// through [reflect.Value.Interface].
+func fieldOf(v reflect.Value, i int) reflect.Value {
+	f := v.Field(i)
+	if f.CanInterface() || !f.CanAddr() {
+		return f
+	}
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
+
+// addressable returns a copy of the struct `v` if it is not addressable, eg., a map value or the value of an
+// interface, so that its unexported fields can be accessed through [fieldOf]. It only does so when values may be
+// passed to stringers or formatters.
+func (d *renderer) addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() || !v.CanInterface() || !d.usesMethods() {
+		return v
+	}
+
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// usesMethods reports whether values may be passed to stringers or formatters, which requires them to be usable
+// through [reflect.Value.Interface].
+func (d *renderer) usesMethods() bool {
+	return d.UseStringer || d.UseStdFormatters || len(d.formatters) > 0 || d.Format == FormatGo
+}
+
+// isNil reports whether `v` is nil, either invalid or a nil interface, pointer, map, slice, function or channel.
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// callStringer calls the Error, String or GoString method of `v`, in that order of preference.
+func callStringer(v any) (string, bool) {
+	switch x := v.(type) {
+	case error:
+		return x.Error(), true
+	case fmt.Stringer:
+		return x.String(), true
+	case fmt.GoStringer:
+		return x.GoString(), true
+	default:
+		return "", false
+	}
+}
+
+// dumpStringer writes the string `s` returned by a method of `v`, annotated with the type of `v`.
+func (d *renderer) dumpStringer(v reflect.Value, s string) {
+	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		d.buf.WriteString(__(d.Theme.Address, "&"))
+		t = t.Elem()
+	}
+
+	d.buf.WriteString(__(d.Theme.Types, t.String()) + __(d.Theme.Braces, "(") + d.quote(s) + __(d.Theme.Braces, ")"))
+}
@@ -0,0 +1,400 @@
+package godump
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// nodeKind is the kind of a [node].
+type nodeKind int
+
+const (
+	nodeNil nodeKind = iota
+	nodeBool
+	nodeNumber
+	nodeString
+	nodeOpaque
+	nodeText
+	nodePointer
+	nodeRef
+	nodeStruct
+	nodeMap
+	nodeList
+
+	// nodePanic is a value whose traversal panicked, its text is the value the panic was called with.
+	nodePanic
+
+	// nodeRedacted is a value that must not be printed, see [Dumper.Redact]. Only its type is printed, its hidden
+	// fingerprint is compared by [Dumper.Fdiff].
+	nodeRedacted
+)
+
+// node is a value as seen by the traversal [Dumper.dump] performs, it is what the formats other than
+// [FormatText] are rendered from.
+type node struct {
+	kind nodeKind
+
+	// typ is the type of the value, it is nil for untyped nils.
+	typ reflect.Type
+
+	// text is the plain text of scalars, eg., numbers, strings, functions, or the output of stringers and formatters.
+	text string
+
+	// id is the pointer id '#x' of pointers, structs, maps and slices, or the id a reference '@x' refers to.
+	id uint
+
+	// folded reports whether the content of a struct, map or slice is folded because of [Dumper.MaxDepth].
+	folded bool
+
+	elem    *node
+	fields  []field
+	entries []pair
+	items   []*node
+
+	// len and cap are the length and capacity of maps and slices.
+	len, cap int
+
+	// elided is the number of items or entries elided right after the first `head` ones.
+	head, elided int
+
+	// truncated is the reason why the elements of a struct, map or list following its last one are not walked,
+	// either the context of [Dumper.FprintContext] being done or [Dumper.MaxBytes] being reached.
+	truncated string
+
+	// hidden is the fingerprint of redacted values, see [renderer.fingerprint]. It is never printed.
+	hidden string
+}
+
+// field is a struct field of a [node].
+type field struct {
+	name  string
+	value *node
+}
+
+// pair is a map entry of a [node].
+type pair struct {
+	key, value *node
+}
+
+// build walks `v` the way [Dumper.dump] does, and returns the tree of nodes it is made of.
+// A value whose traversal panics is a node of its own, so that its siblings are still walked, and the pointer ids
+// given within it are forgotten. The size of the tree is accounted for [Dumper.MaxBytes], see [renderer.used].
+func (d *renderer) build(v reflect.Value) (n *node) {
+	depth, ptrID := d.depth, d.ptrID
+	defer func() {
+		if e := recover(); e != nil {
+			d.depth, d.ptrTag = depth, 0
+			d.forget(ptrID)
+			n = &node{kind: nodePanic, text: panicMessage(e)}
+			if v.IsValid() {
+				n.typ = v.Type()
+			}
+		}
+		d.size += 1 + len(n.text)
+	}()
+
+	if f := d.formatter(v); f != nil {
+		if s, ok := d.formatted(v, f); ok {
+			return &node{kind: nodeText, typ: v.Type(), text: s}
+		}
+	}
+
+	if d.UseStringer && d.Format != FormatGo {
+		if s, ok := stringer(v); ok {
+			return &node{kind: nodeText, typ: v.Type(), text: s}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return &node{kind: nodeNil}
+	case reflect.Interface:
+		return d.build(v.Elem())
+	case reflect.String:
+		return &node{kind: nodeString, typ: v.Type(), text: v.String()}
+	case reflect.Bool:
+		return &node{kind: nodeBool, typ: v.Type(), text: strconv.FormatBool(v.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &node{kind: nodeNumber, typ: v.Type(), text: strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &node{kind: nodeNumber, typ: v.Type(), text: strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return &node{kind: nodeNumber, typ: v.Type(), text: strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())}
+	case reflect.Complex64, reflect.Complex128:
+		return &node{kind: nodeNumber, typ: v.Type(), text: strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits())}
+	case reflect.Uintptr:
+		return &node{kind: nodeNumber, typ: v.Type(), text: fmt.Sprintf("0x%x", v.Uint())}
+	case reflect.Func:
+		if v.IsNil() {
+			return &node{kind: nodeNil, typ: v.Type()}
+		}
+		return &node{kind: nodeOpaque, typ: v.Type(), text: v.Type().String()}
+	case reflect.Chan:
+		if v.IsNil() {
+			return &node{kind: nodeNil, typ: v.Type()}
+		}
+		text := v.Type().String()
+		if c := v.Cap(); c > 0 {
+			text += fmt.Sprintf("<%d>", c)
+		}
+		return &node{kind: nodeOpaque, typ: v.Type(), text: text, cap: v.Cap()}
+	case reflect.UnsafePointer:
+		if v.IsNil() {
+			return &node{kind: nodeNil, typ: v.Type()}
+		}
+		return &node{kind: nodeOpaque, typ: v.Type(), text: fmt.Sprintf("%s(0x%x)", v.Type(), uintptr(v.UnsafePointer()))}
+	case reflect.Pointer:
+		return d.buildPointer(v)
+	case reflect.Struct:
+		return d.buildStruct(v)
+	case reflect.Map:
+		return d.buildMap(v)
+	default:
+		return d.buildList(v)
+	}
+}
+
+// formatted returns the plain text `f` formats `v` into, it reports false if `v` cannot be passed to it.
+// The output of registered formatters is captured without styling and at depth zero.
+// With [FormatGo], it returns the Go expression of `v` instead, and reports false if it is unknown.
+func (d *renderer) formatted(v reflect.Value, f *formatter) (string, bool) {
+	if d.Format == FormatGo {
+		v, ok := exported(v)
+		if !ok || f.syntax == nil {
+			return "", false
+		}
+		return f.syntax(v.Interface()), true
+	}
+
+	if f.text != nil {
+		v, ok := exported(v)
+		if !ok {
+			return "", false
+		}
+		return f.text(v.Interface()), true
+	}
+
+	var ok bool
+	theme, depth := d.Theme, d.depth
+	d.Theme, d.depth = Theme{}, 0
+	defer func() { d.Theme, d.depth = theme, depth }()
+
+	s := d.capture(func() { ok = d.dumpFormatter(v, f) })
+	return s, ok
+}
+
+func (d *renderer) buildPointer(v reflect.Value) *node {
+	if v.IsNil() {
+		return &node{kind: nodeNil, typ: v.Type()}
+	}
+
+	// Pointers to primitives are not tagged, except in Go syntax where those referred to several times are hoisted.
+	n := &node{kind: nodePointer, typ: v.Type()}
+	if isPrimitive(v.Elem()) && d.Format != FormatGo {
+		n.elem = d.build(v.Elem())
+		return n
+	}
+
+	id, seen := d.pointerID(v)
+	if seen {
+		return &node{kind: nodeRef, typ: v.Type(), id: id}
+	}
+
+	n.id = id
+	d.ptrTag = id
+	n.elem = d.build(v.Elem())
+	d.ptrTag = 0
+
+	return n
+}
+
+func (d *renderer) buildStruct(v reflect.Value) *node {
+	v = d.addressable(v)
+	n := &node{kind: nodeStruct, typ: v.Type(), id: d.ptrTag}
+	d.ptrTag = 0
+
+	if d.countFields(n.typ) > 0 && d.tooDeep() {
+		n.folded = true
+		return n
+	}
+
+	if d.Format == FormatGo && sealed(n.typ) {
+		return n
+	}
+
+	d.depth++
+	for i := 0; i < v.NumField(); i++ {
+		f := n.typ.Field(i)
+		if !f.IsExported() && d.HidePrivateFields {
+			continue
+		}
+		if d.stopping(n) {
+			break
+		}
+		d.size += len(f.Name)
+		if d.redactsField(f) {
+			n.fields = append(n.fields, field{name: f.Name, value: d.buildRedacted(fieldOf(v, i))})
+			continue
+		}
+		n.fields = append(n.fields, field{name: f.Name, value: d.build(fieldOf(v, i))})
+	}
+	d.depth--
+
+	return n
+}
+
+func (d *renderer) buildMap(v reflect.Value) *node {
+	id, seen := d.refID(v)
+	if seen {
+		return &node{kind: nodeRef, typ: v.Type(), id: id}
+	}
+
+	if v.IsNil() {
+		return &node{kind: nodeNil, typ: v.Type()}
+	}
+
+	n := &node{kind: nodeMap, typ: v.Type(), id: id, len: v.Len()}
+
+	if n.len > 0 && d.tooDeep() {
+		n.folded = true
+		return n
+	}
+
+	var entries []entry
+	entries, n.head, n.elided = d.mapEntries(v, d.budget())
+
+	d.depth++
+	for _, en := range entries {
+		if d.stopping(n) {
+			break
+		}
+		e := pair{key: d.build(en.key)}
+		if d.redactsKey(en.key) {
+			e.value = d.buildRedacted(en.value)
+		} else {
+			e.value = d.build(en.value)
+		}
+		n.entries = append(n.entries, e)
+	}
+	d.stopping(n) // the entries may have been cut short by mapEntries.
+	d.depth--
+
+	return n
+}
+
+// buildList builds the node of the slice or array `v`.
+func (d *renderer) buildList(v reflect.Value) *node {
+	id, seen := d.refID(v)
+	if seen {
+		return &node{kind: nodeRef, typ: v.Type(), id: id}
+	}
+
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return &node{kind: nodeNil, typ: v.Type()}
+	}
+
+	n := &node{kind: nodeList, typ: v.Type(), id: id, len: v.Len(), cap: v.Cap()}
+
+	if n.len > 0 && d.tooDeep() {
+		n.folded = true
+		return n
+	}
+
+	n.head, n.elided = d.elide(n.len)
+
+	d.depth++
+	for i := 0; i < n.len; i++ {
+		if i == n.head && n.elided > 0 {
+			i += n.elided - 1
+			continue
+		}
+		if d.stopping(n) {
+			break
+		}
+		n.items = append(n.items, d.build(v.Index(i)))
+	}
+	d.depth--
+
+	return n
+}
+
+// stopping reports whether the walk of the elements of the struct, map or list node `n` is to stop, see
+// [renderer.truncated]. The first node it stops records the reason, the nodes enclosing it stop as well.
+func (d *renderer) stopping(n *node) bool {
+	if d.halted {
+		return true
+	}
+
+	reason := d.stopReason()
+	if reason == "" {
+		return false
+	}
+
+	d.halted = true
+	n.truncated = reason
+	return true
+}
+
+// header returns the type of the struct, map or list node `n` the way the text format prints it,
+// along with the length and capacity of maps and slices.
+func header(n *node) string {
+	switch n.typ.Kind() {
+	case reflect.Struct:
+		if t := n.typ.String(); strings.HasPrefix(t, "struct") {
+			return "struct"
+		}
+	case reflect.Map:
+		return fmt.Sprintf("%s:%d", n.typ, n.len)
+	case reflect.Slice:
+		return fmt.Sprintf("%s:%d:%d", n.typ, n.len, n.cap)
+	}
+	return n.typ.String()
+}
+
+// writeLeaf writes the node `n`, which is neither a pointer, a reference, a struct, a map nor a list,
+// the way the text format does.
+func (d *renderer) writeLeaf(n *node) {
+	switch n.kind {
+	case nodeNil:
+		if n.typ == nil {
+			d.buf.WriteString(__(d.Theme.Nil, "nil"))
+			return
+		}
+		d.buf.WriteString(__(d.typeStyle(n.typ), n.typ.String()))
+		d.writeNil()
+	case nodeBool:
+		d.buf.WriteString(__(d.Theme.Bool, n.text))
+	case nodeNumber:
+		d.buf.WriteString(__(d.Theme.Number, n.text))
+	case nodeString:
+		d.buf.WriteString(d.quote(n.text))
+	case nodeOpaque:
+		if n.typ.Kind() == reflect.UnsafePointer {
+			addr := strings.TrimSuffix(strings.TrimPrefix(n.text, n.typ.String()+"("), ")")
+			d.buf.WriteString(__(d.Theme.Types, n.typ.String()) + __(d.Theme.Braces, "(") +
+				__(d.Theme.UnsafePointer, addr) + __(d.Theme.Braces, ")"))
+			return
+		}
+		d.buf.WriteString(__(d.typeStyle(n.typ), n.text))
+	case nodeText:
+		d.buf.WriteString(__(d.Theme.Types, n.typ.String()) + __(d.Theme.Braces, "(") + d.quote(n.text) + __(d.Theme.Braces, ")"))
+	case nodePanic:
+		d.writePanic(n.text)
+	case nodeRedacted:
+		d.writeRedacted(n.typ)
+	}
+}
+
+// typeStyle returns the style of the type `t`, which is specific to functions and channels.
+func (d *renderer) typeStyle(t reflect.Type) Style {
+	switch t.Kind() {
+	case reflect.Func:
+		return d.Theme.Func
+	case reflect.Chan:
+		return d.Theme.Chan
+	default:
+		return d.Theme.Types
+	}
+}
@@ -0,0 +1,338 @@
+package godump
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// writeYAMLDocument writes the node `n` as a YAML document, see [FormatYAML].
+func (d *renderer) writeYAMLDocument(n *node) {
+	indentation := d.Indentation
+	if len(d.Indentation) < 2 || strings.Trim(d.Indentation, " ") != "" {
+		d.Indentation = "  "
+	}
+
+	d.writeYAML(n, "", false)
+	d.Indentation = indentation
+}
+
+// writeYAML writes the node `n` at the current depth, after a mapping key, a sequence dash or nothing.
+// The content of `n` is separated from what precedes it by `gap` when it fits on the same line.
+// Mappings and sequences are moved to their own lines when `wrap` is set, as required after mapping keys.
+func (d *renderer) writeYAML(n *node, gap string, wrap bool) {
+	d.flush()
+
+	var anchor uint
+	for n.kind == nodePointer {
+		if anchor == 0 {
+			anchor = n.id
+		}
+		n = n.elem
+	}
+
+	if n.kind == nodeRef {
+		d.buf.WriteString(gap + "*" + yamlAnchor(n.id))
+		return
+	}
+
+	if anchor == 0 {
+		anchor = n.id
+	}
+
+	if anchor != 0 {
+		d.buf.WriteString(gap + "&" + yamlAnchor(anchor))
+		gap, wrap = " ", true
+	}
+
+	if !yamlBlock(n) {
+		d.buf.WriteString(gap)
+		d.writeYAMLScalar(n)
+		return
+	}
+
+	if wrap {
+		d.buf.WriteString("\n")
+		d.indent()
+	} else {
+		d.buf.WriteString(gap)
+	}
+
+	if n.kind == nodeList {
+		d.writeYAMLSequence(n)
+	} else {
+		d.writeYAMLMapping(n)
+	}
+}
+
+// writeYAMLSequence writes the items of the list node `n`, the first one on the current line.
+func (d *renderer) writeYAMLSequence(n *node) {
+	pad := d.Indentation[1:]
+
+	for i := 0; i <= len(n.items); i++ {
+		if i == n.head && n.elided > 0 {
+			d.nextYAMLLine(i == 0)
+			d.writeYAMLElision(n.elided)
+		}
+
+		if i < len(n.items) {
+			d.nextYAMLLine(i == 0 && (n.head != 0 || n.elided == 0))
+			d.buf.WriteString("-")
+			d.depth++
+			d.writeYAML(n.items[i], pad, false)
+			d.depth--
+		}
+	}
+	d.writeYAMLTruncation(n, len(n.items) == 0 && n.elided == 0)
+}
+
+// writeYAMLMapping writes the fields of the struct node `n` or the entries of the map node `n`,
+// the first one on the current line. Keys that cannot be written as plain or quoted scalars are written
+// as explicit keys, following a '?'.
+func (d *renderer) writeYAMLMapping(n *node) {
+	if n.kind == nodeStruct {
+		for i, f := range n.fields {
+			d.nextYAMLLine(i == 0)
+			d.buf.WriteString(yamlString(f.name) + ":")
+			d.depth++
+			d.writeYAML(f.value, " ", true)
+			d.depth--
+		}
+		d.writeYAMLTruncation(n, len(n.fields) == 0)
+		return
+	}
+
+	pad := d.Indentation[1:]
+
+	for i := 0; i <= len(n.entries); i++ {
+		if i == n.head && n.elided > 0 {
+			d.nextYAMLLine(i == 0)
+			d.writeYAMLElision(n.elided)
+		}
+
+		if i == len(n.entries) {
+			d.writeYAMLTruncation(n, i == 0 && n.elided == 0)
+			break
+		}
+
+		d.nextYAMLLine(i == 0 && (n.head != 0 || n.elided == 0))
+		e := n.entries[i]
+
+		d.depth++
+		if key, ok := yamlKey(e.key); ok {
+			d.buf.WriteString(key + ":")
+			d.writeYAML(e.value, " ", true)
+		} else {
+			d.buf.WriteString("?")
+			d.writeYAML(e.key, pad, false)
+			d.depth--
+			d.nextYAMLLine(false)
+			d.depth++
+			d.buf.WriteString(":")
+			d.writeYAML(e.value, pad, false)
+		}
+		d.depth--
+	}
+}
+
+// nextYAMLLine starts a new line at the current depth, unless it is the first line of a mapping or a sequence.
+func (d *renderer) nextYAMLLine(first bool) {
+	if !first {
+		d.buf.WriteString("\n")
+		d.indent()
+	}
+}
+
+// writeYAMLElision writes the comment marking `n` elided items.
+func (d *renderer) writeYAMLElision(n int) {
+	d.buf.WriteString(fmt.Sprintf("# … %s more …", thousands(n)))
+}
+
+// writeYAMLTruncation writes the comment marking the truncation of the mapping or sequence node `n`, if any,
+// on the current line if it is the `first` one.
+func (d *renderer) writeYAMLTruncation(n *node, first bool) {
+	if n.truncated != "" {
+		d.nextYAMLLine(first)
+		d.buf.WriteString("# … truncated (" + n.truncated + ")")
+	}
+}
+
+// writeYAMLScalar writes the node `n`, which is neither a non-empty mapping nor a non-empty sequence.
+func (d *renderer) writeYAMLScalar(n *node) {
+	switch n.kind {
+	case nodeNil:
+		d.buf.WriteString("null")
+	case nodeBool:
+		d.buf.WriteString(n.text)
+	case nodeNumber:
+		d.buf.WriteString(yamlNumber(n.text))
+	case nodePanic:
+		d.buf.WriteString(yamlString("<panic: " + n.text + ">"))
+	case nodeRedacted:
+		d.buf.WriteString(yamlString(redacted) + " # " + n.typ.String())
+	case nodeString, nodeOpaque, nodeText:
+		if yamlLiteral(n.text) {
+			d.writeYAMLLiteral(n.text)
+		} else {
+			d.buf.WriteString(yamlString(n.text))
+		}
+	case nodeStruct:
+		d.buf.WriteString("{}")
+		if n.folded {
+			d.buf.WriteString(fmt.Sprintf(" # …%d fields", d.countFields(n.typ)))
+		}
+	case nodeMap:
+		d.buf.WriteString("{}")
+		if n.folded {
+			d.buf.WriteString(" # …")
+		}
+	case nodeList:
+		d.buf.WriteString("[]")
+		if n.folded {
+			d.buf.WriteString(" # …")
+		}
+	}
+}
+
+// writeYAMLLiteral writes the string `s` spanning several lines as a literal block scalar, its lines indented
+// at the current depth, or one level deeper at the top of the document.
+func (d *renderer) writeYAMLLiteral(s string) {
+	lines := strings.Split(s, "\n")
+
+	switch {
+	case !strings.HasSuffix(s, "\n"):
+		d.buf.WriteString("|-")
+	case strings.HasSuffix(s, "\n\n"):
+		d.buf.WriteString("|+")
+		lines = lines[:len(lines)-1]
+	default:
+		d.buf.WriteString("|")
+		lines = lines[:len(lines)-1]
+	}
+
+	depth := d.depth
+	if d.depth == 0 {
+		d.depth++
+	}
+	for _, line := range lines {
+		d.buf.WriteString("\n")
+		if line != "" {
+			d.indent()
+			d.buf.WriteString(line)
+		}
+	}
+	d.depth = depth
+}
+
+// yamlBlock reports whether the node `n` is written as a block mapping or sequence.
+func yamlBlock(n *node) bool {
+	switch n.kind {
+	case nodeStruct:
+		return len(n.fields) > 0 || n.truncated != ""
+	case nodeMap:
+		return len(n.entries) > 0 || n.elided > 0 || n.truncated != ""
+	case nodeList:
+		return len(n.items) > 0 || n.elided > 0 || n.truncated != ""
+	default:
+		return false
+	}
+}
+
+// yamlKey returns the node `n` as an implicit mapping key, it reports false if `n` can only be an explicit key.
+func yamlKey(n *node) (string, bool) {
+	for n.kind == nodePointer && n.id == 0 {
+		n = n.elem
+	}
+
+	switch n.kind {
+	case nodeNil:
+		return "null", true
+	case nodeBool:
+		return n.text, true
+	case nodeNumber:
+		return yamlNumber(n.text), true
+	case nodeString, nodeOpaque, nodeText:
+		return yamlString(n.text), true
+	default:
+		return "", false
+	}
+}
+
+// yamlAnchor returns the name of the anchor of the pointer id `id`, eg., id001.
+func yamlAnchor(id uint) string {
+	return fmt.Sprintf("id%03d", id)
+}
+
+// yamlNumber returns the number `s` the way YAML spells it.
+func yamlNumber(s string) string {
+	switch s {
+	case "NaN":
+		return ".nan"
+	case "+Inf":
+		return ".inf"
+	case "-Inf":
+		return "-.inf"
+	default:
+		return s
+	}
+}
+
+// yamlString returns `s` as a plain scalar if it would be read back as the same string, or double-quoted otherwise.
+func yamlString(s string) string {
+	if yamlPlain(s) {
+		return s
+	}
+	return jsonQuote(s)
+}
+
+// yamlPlain reports whether `s` can be written as a plain scalar. It errs on the side of quoting.
+func yamlPlain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`+.=<~") {
+		return false
+	}
+
+	if s[0] >= '0' && s[0] <= '9' || strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+
+	switch strings.ToLower(s) {
+	case "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return false
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+
+	for _, r := range s {
+		if r == utf8.RuneError || !unicode.IsPrint(r) && r != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// yamlLiteral reports whether `s` should be written as a literal block scalar, which is when it spans several lines
+// made of printable characters only. Strings whose first non-empty line is indented, or having blank lines made of
+// spaces, are quoted instead as the indentation of their lines could not be told apart. So are strings made of new
+// lines only, which a block scalar cannot hold.
+func yamlLiteral(s string) bool {
+	if !strings.Contains(s, "\n") || strings.Trim(s, "\n") == "" || strings.HasPrefix(strings.TrimLeft(s, "\n"), " ") {
+		return false
+	}
+
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" && strings.Trim(line, " ") == "" {
+			return false
+		}
+	}
+
+	for _, r := range s {
+		if r == utf8.RuneError || !unicode.IsPrint(r) && r != ' ' && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}